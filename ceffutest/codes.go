@@ -0,0 +1,51 @@
+package ceffutest
+
+import (
+	"errors"
+
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+// API response codes of the fake server. They are not Ceffu's codes, which are not
+// documented: Server.Client maps them to errors with client.Options.ErrorCodes, see
+// ErrorCodes.
+const (
+	CodeInvalidParameter      = "100001"
+	CodeInvalidSignature      = "100002"
+	CodeInvalidAPIKey         = "100003"
+	CodeRateLimited           = "100006"
+	CodeWalletNotFound        = "200001"
+	CodeOrderNotFound         = "200002"
+	CodeInsufficientBalance   = "200003"
+	CodeAddressNotWhitelisted = "200004"
+	CodeDuplicateRequestID    = "200005"
+	CodeSystemError           = "900001"
+)
+
+// Errors matched with errors.Is by the request errors of the fake server that have no
+// client sentinel.
+var (
+	ErrInvalidParameter    = errors.New("ceffutest: invalid parameter")
+	ErrInvalidSignature    = errors.New("ceffutest: invalid signature")
+	ErrWalletNotFound      = errors.New("ceffutest: wallet not found")
+	ErrOrderNotFound       = errors.New("ceffutest: order not found")
+	ErrInsufficientBalance = errors.New("ceffutest: insufficient balance")
+	ErrDuplicateRequestID  = errors.New("ceffutest: duplicate request id")
+)
+
+// ErrorCodes returns the errors of the API codes of the fake server, to be used as
+// client.Options.ErrorCodes.
+func ErrorCodes() map[string]error {
+	return map[string]error{
+		CodeInvalidParameter:      ErrInvalidParameter,
+		CodeInvalidSignature:      ErrInvalidSignature,
+		CodeInvalidAPIKey:         client.ErrUnauthorized,
+		CodeRateLimited:           client.ErrRateLimited,
+		CodeWalletNotFound:        ErrWalletNotFound,
+		CodeOrderNotFound:         ErrOrderNotFound,
+		CodeInsufficientBalance:   ErrInsufficientBalance,
+		CodeAddressNotWhitelisted: client.ErrAddressNotWhitelisted,
+		CodeDuplicateRequestID:    ErrDuplicateRequestID,
+		CodeSystemError:           client.ErrServer,
+	}
+}
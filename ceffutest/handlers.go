@@ -0,0 +1,444 @@
+package ceffutest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+const defaultPageLimit = 10
+
+func (s *Server) routes() map[string]map[string]handlerFunc {
+	return map[string]map[string]handlerFunc{
+		client.PathCreateSubWallet:            {http.MethodPost: s.createSubWallet},
+		client.PathGetDepositAddress:          {http.MethodGet: s.getDepositAddress},
+		client.PathDepositHistory:             {http.MethodGet: s.getDepositHistory},
+		client.PathTransfer:                   {http.MethodPost: s.transfer},
+		client.PathWithdrawal:                 {http.MethodPost: s.withdrawal},
+		client.PathWithdrawalDetail:           {http.MethodGet: s.getWithdrawalDetail},
+		client.PathTransferWithExchange:       {http.MethodPost: s.transferWithExchange},
+		client.PathTransferDetailWithExchange: {http.MethodPost: s.getTransferDetailWithExchange},
+	}
+}
+
+type query struct {
+	values url.Values
+	err    error
+}
+
+func parseQuery(r *http.Request) *query {
+	return &query{values: r.URL.Query()}
+}
+
+func (q *query) string(name string) string {
+	return q.values.Get(name)
+}
+
+func (q *query) int64(name string) int64 {
+	value := q.values.Get(name)
+	if value == "" || q.err != nil {
+		return 0
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		q.err = errorf(CodeInvalidParameter, "invalid "+name)
+	}
+	return i
+}
+
+// timeRange returns the requested time range, and fails if it is longer than client.MaxHistoryWindow.
+func (q *query) timeRange() (int64, int64) {
+	startTime, endTime := q.int64("startTime"), q.int64("endTime")
+	if q.err == nil && endTime != 0 && endTime-startTime > client.MaxHistoryWindow.Milliseconds() {
+		q.err = errorf(CodeInvalidParameter, "time interval must be within 0-30 days")
+	}
+	return startTime, endTime
+}
+
+func inRange(createdAt, startTime, endTime int64) bool {
+	return createdAt >= startTime && (endTime == 0 || createdAt <= endTime)
+}
+
+type page struct {
+	Data      interface{} `json:"data"`
+	TotalPage int         `json:"totalPage"`
+	PageNo    int         `json:"pageNo"`
+	PageLimit int         `json:"pageLimit"`
+}
+
+// paginate returns the bounds of the requested page of n items.
+func (q *query) paginate(n int) (start, end int, p page) {
+	pageNo, pageLimit := int(q.int64("pageNo")), int(q.int64("pageLimit"))
+	if pageNo < 1 {
+		pageNo = 1
+	}
+	if pageLimit < 1 {
+		pageLimit = defaultPageLimit
+	}
+	p = page{
+		TotalPage: (n + pageLimit - 1) / pageLimit,
+		PageNo:    pageNo,
+		PageLimit: pageLimit,
+	}
+	start = (pageNo - 1) * pageLimit
+	if start > n {
+		start = n
+	}
+	end = start + pageLimit
+	if end > n {
+		end = n
+	}
+	return start, end, p
+}
+
+func decode(payload []byte, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return errorf(CodeInvalidParameter, "invalid request body")
+	}
+	return nil
+}
+
+func (s *Server) createSubWallet(_ *http.Request, payload []byte) (interface{}, error) {
+	request := types.CreatSubWalletRequest{}
+	if err := decode(payload, &request); err != nil {
+		return nil, err
+	}
+	parentWalletID, err := strconv.ParseInt(request.ParentWalletID, 10, 64)
+	if err != nil {
+		return nil, errorf(CodeInvalidParameter, "invalid parentWalletId")
+	}
+	parent, err := s.wallet(parentWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.isSub() {
+		return nil, errorf(CodeInvalidParameter, "parent wallet must be a prime wallet")
+	}
+	if len(request.WalletName) > 20 {
+		return nil, errorf(CodeInvalidParameter, "walletName exceeds 20 characters")
+	}
+	if err := s.useRequestID(client.PathCreateSubWallet, request.RequestID); err != nil {
+		return nil, err
+	}
+	return s.createWallet(request.WalletName, parentWalletID).info, nil
+}
+
+func (s *Server) getDepositAddress(r *http.Request, _ []byte) (interface{}, error) {
+	q := parseQuery(r)
+	walletID := q.int64("walletId")
+	if q.err != nil {
+		return nil, q.err
+	}
+	if _, err := s.wallet(walletID); err != nil {
+		return nil, err
+	}
+	network := q.string("network")
+	if network == "" {
+		return nil, errorf(CodeInvalidParameter, "network is required")
+	}
+	return map[string]string{
+		"walletAddress": s.depositAddress(walletID, q.string("coinSymbol"), network),
+		"memo":          "",
+	}, nil
+}
+
+func (s *Server) transactionHistory(r *http.Request, orders []*order) (interface{}, error) {
+	q := parseQuery(r)
+	walletID := q.int64("walletId")
+	status := q.int64("status")
+	startTime, endTime := q.timeRange()
+	if q.err != nil {
+		return nil, q.err
+	}
+	if _, err := s.wallet(walletID); err != nil {
+		return nil, err
+	}
+
+	transactions := []*types.Transaction{}
+	for _, o := range orders {
+		switch {
+		case !s.belongsTo(o.walletID, walletID),
+			!inRange(o.createdAt, startTime, endTime),
+			q.string("coinSymbol") != "" && q.string("coinSymbol") != o.symbol,
+			q.string("network") != "" && q.string("network") != o.network,
+			status != 0 && status != o.tx.Status:
+			continue
+		}
+		transactions = append(transactions, o.tx)
+	}
+	start, end, p := q.paginate(len(transactions))
+	p.Data = transactions[start:end]
+	return p, q.err
+}
+
+func (s *Server) getDepositHistory(r *http.Request, _ []byte) (interface{}, error) {
+	return s.transactionHistory(r, s.deposits)
+}
+
+func (s *Server) transfer(_ *http.Request, payload []byte) (interface{}, error) {
+	request := types.TransferRequest{}
+	if err := decode(payload, &request); err != nil {
+		return nil, err
+	}
+	from, err := s.wallet(request.FromWalletID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.wallet(request.ToWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Amount.Sign() <= 0 {
+		return nil, errorf(CodeInvalidParameter, "amount must be positive")
+	}
+
+	var direction int32
+	switch {
+	case !from.isSub() && to.info.ParentWalletId == from.info.WalletId:
+		direction = types.TransferDirectionParentWalletToSubWallet
+	case from.info.ParentWalletId == to.info.WalletId:
+		direction = types.TransferDirectionSubWalletToParentWallet
+	case from.isSub() && to.isSub() && from.info.ParentWalletId == to.info.ParentWalletId:
+		direction = types.TransferDirectionSubWalletToSubWallet
+	default:
+		return nil, errorf(CodeInvalidParameter, "wallets are not under the same prime wallet")
+	}
+
+	// debit the networks of the coin in order, and credit the same networks
+	var networks []string
+	total := types.Amount{}
+	for _, key := range sortedKeys(from.balances) {
+		if key.symbol == request.CoinSymbol {
+			networks = append(networks, key.network)
+			total = total.Add(from.balances[key].available)
+		}
+	}
+	if total.Cmp(request.Amount.Amount) < 0 {
+		return nil, errorf(CodeInsufficientBalance, "insufficient balance")
+	}
+	if err := s.useRequestID(client.PathTransfer, request.RequestID); err != nil {
+		return nil, err
+	}
+	remaining := request.Amount.Amount
+	for _, network := range networks {
+		b := from.balance(request.CoinSymbol, network)
+		amount := b.available
+		if amount.Cmp(remaining) > 0 {
+			amount = remaining
+		}
+		b.available = b.available.Sub(amount)
+		to.balance(request.CoinSymbol, network).available = to.balance(request.CoinSymbol, network).available.Add(amount)
+		remaining = remaining.Sub(amount)
+		if remaining.IsZero() {
+			break
+		}
+	}
+
+	t := &transfer{
+		orderViewID:  s.nextID(""),
+		symbol:       request.CoinSymbol,
+		amount:       request.Amount.Amount,
+		fromWalletID: request.FromWalletID,
+		toWalletID:   request.ToWalletID,
+		direction:    direction,
+		requestID:    request.RequestID,
+		createdAt:    s.Now().UnixMilli(),
+	}
+	s.transfers = append(s.transfers, t)
+	return types.Transfer{
+		OrderViewId: t.orderViewID,
+		Status:      types.TransactionStatusSuccess,
+		Direction:   t.direction,
+	}, nil
+}
+
+func (s *Server) withdrawal(_ *http.Request, payload []byte) (interface{}, error) {
+	request := types.WithdrawalRequest{}
+	if err := decode(payload, &request); err != nil {
+		return nil, err
+	}
+	w, err := s.wallet(request.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if w.isSub() {
+		return nil, errorf(CodeInvalidParameter, "withdrawal is only applicable to parent wallets")
+	}
+	if (request.WithdrawalAddress == "") == (request.ToWalletIDStr == "") {
+		return nil, errorf(CodeInvalidParameter, "either withdrawalAddress or toWalletIdStr must be provided")
+	}
+	if request.Amount.Sign() <= 0 {
+		return nil, errorf(CodeInvalidParameter, "amount must be positive")
+	}
+
+	var to *wallet
+	if request.ToWalletIDStr != "" {
+		toWalletID, err := strconv.ParseInt(request.ToWalletIDStr, 10, 64)
+		if err != nil {
+			return nil, errorf(CodeInvalidParameter, "invalid toWalletIdStr")
+		}
+		if to, err = s.wallet(toWalletID); err != nil {
+			return nil, err
+		}
+	} else if !w.whitelisted(request.Network, request.WithdrawalAddress) {
+		return nil, errorf(CodeAddressNotWhitelisted, "address not whitelisted")
+	}
+
+	key := assetKey{symbol: request.CoinSymbol, network: request.Network}
+	if network, ok := s.coinNetworks[key]; ok {
+		switch {
+		case request.Amount.Cmp(network.WithdrawMin) < 0:
+			return nil, errorf(CodeInvalidParameter, "amount is lower than the minimum")
+		case !network.WithdrawMax.IsZero() && request.Amount.Cmp(network.WithdrawMax) > 0:
+			return nil, errorf(CodeInvalidParameter, "amount is greater than the maximum")
+		case network.MemoRequired && request.ToWalletIDStr == "" && request.Memo == "":
+			return nil, errorf(CodeInvalidParameter, "memo is required")
+		}
+	}
+
+	fee := s.withdrawalFees[key]
+	if request.CustomizeFeeAmount != nil {
+		fee = *request.CustomizeFeeAmount
+	}
+	total := request.Amount.Add(fee)
+	b := w.balance(request.CoinSymbol, request.Network)
+	if b.available.Cmp(total) < 0 {
+		return nil, errorf(CodeInsufficientBalance, "insufficient balance")
+	}
+	if err := s.useRequestID(client.PathWithdrawal, request.RequestID); err != nil {
+		return nil, err
+	}
+	b.available = b.available.Sub(total)
+	b.frozen = b.frozen.Add(total)
+
+	o := s.newTransaction(w.info.WalletId, types.TransactionDirectionWithdrawal, request.CoinSymbol, request.Network, request.Amount, s.Now().UnixMilli())
+	o.frozen = total
+	o.tx.FeeAmount = fee
+	o.tx.FromAddress = s.depositAddress(w.info.WalletId, request.CoinSymbol, request.Network)
+	o.tx.ToAddress = request.WithdrawalAddress
+	if request.Memo != "" {
+		memo := request.Memo
+		o.tx.Memo = &memo
+	}
+	requestID := request.RequestID
+	o.tx.RequestID = &requestID
+	if to != nil {
+		o.toWalletID = to.info.WalletId
+		o.tx.TransferType = types.TransferTypeInternal
+		o.tx.ToAddress = s.depositAddress(to.info.WalletId, request.CoinSymbol, request.Network)
+	} else {
+		o.tx.TxID = s.txHash(o.tx.OrderViewID)
+	}
+	s.withdrawals = append(s.withdrawals, o)
+
+	return types.WithdrawalResponseData{
+		OrderViewId:  o.tx.OrderViewID,
+		Status:       int(o.tx.Status),
+		TransferType: int(o.tx.TransferType),
+	}, nil
+}
+
+func (s *Server) getWithdrawalDetail(r *http.Request, _ []byte) (interface{}, error) {
+	q := parseQuery(r)
+	orderViewID, requestID := q.string("orderViewId"), q.string("requestId")
+	if orderViewID == "" && requestID == "" {
+		return nil, errorf(CodeInvalidParameter, "orderViewId or requestId is required")
+	}
+	for _, o := range s.withdrawals {
+		if (orderViewID == "" || o.tx.OrderViewID == orderViewID) && (requestID == "" || *o.tx.RequestID == requestID) {
+			return o.tx, nil
+		}
+	}
+	return nil, errorf(CodeOrderNotFound, "withdrawal not found")
+}
+
+func (s *Server) transferWithExchange(_ *http.Request, payload []byte) (interface{}, error) {
+	request := types.TransferWithExchangeRequest{}
+	if err := decode(payload, &request); err != nil {
+		return nil, err
+	}
+	w, err := s.wallet(request.ParentWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if w.isSub() {
+		return nil, errorf(CodeInvalidParameter, "transfer with exchange is only applicable to parent wallets")
+	}
+	if request.ExchangeCode != types.ExchangeCodeBinance || request.ExchangeUserID == "" {
+		return nil, errorf(CodeInvalidParameter, "invalid exchange account")
+	}
+	if request.Direction != 0 && request.Direction != types.ExchangeTransferDirectionCustodyToExchange {
+		return nil, errorf(CodeInvalidParameter, "only custody to exchange direction is supported")
+	}
+	if request.Amount.Sign() <= 0 {
+		return nil, errorf(CodeInvalidParameter, "amount must be positive")
+	}
+
+	// exchange transfers are not bound to a network, use the first network holding enough of the coin
+	var b *balance
+	var network string
+	for _, key := range sortedKeys(w.balances) {
+		if key.symbol == request.CoinSymbol && w.balances[key].available.Cmp(request.Amount) >= 0 {
+			b, network = w.balances[key], key.network
+			break
+		}
+	}
+	if b == nil {
+		return nil, errorf(CodeInsufficientBalance, "insufficient balance")
+	}
+	if err := s.useRequestID(client.PathTransferWithExchange, request.RequestID); err != nil {
+		return nil, err
+	}
+	b.available = b.available.Sub(request.Amount)
+	b.frozen = b.frozen.Add(request.Amount)
+
+	o := &order{
+		walletID:  w.info.WalletId,
+		symbol:    request.CoinSymbol,
+		network:   network,
+		frozen:    request.Amount,
+		createdAt: s.Now().UnixMilli(),
+		exchange: &types.TransferDetail{
+			Amount:         request.Amount,
+			CoinSymbol:     request.CoinSymbol,
+			Direction:      types.ExchangeTransferDirectionCustodyToExchange,
+			ExchangeCode:   int32(request.ExchangeCode),
+			ExchangeUserID: request.ExchangeUserID,
+			OrderViewID:    s.nextID(""),
+			Status:         types.TransactionStatusPending,
+			WalletID:       w.info.WalletId,
+			RequestId:      request.RequestID,
+		},
+	}
+	o.exchange.CreateTime = o.createdAt
+	s.orders[o.exchange.OrderViewID] = o
+	s.exchangeTransfers = append(s.exchangeTransfers, o)
+
+	return types.Transfer{
+		OrderViewId: o.exchange.OrderViewID,
+		Status:      o.exchange.Status,
+		Direction:   o.exchange.Direction,
+	}, nil
+}
+
+func (s *Server) getTransferDetailWithExchange(_ *http.Request, payload []byte) (interface{}, error) {
+	request := types.TransferDetailWithExchangeRequest{}
+	if err := decode(payload, &request); err != nil {
+		return nil, err
+	}
+	if request.OrderViewID == "" && request.RequestID == "" {
+		return nil, errorf(CodeInvalidParameter, "orderViewId or requestId is required")
+	}
+	for _, o := range s.exchangeTransfers {
+		if (request.OrderViewID == "" || o.exchange.OrderViewID == request.OrderViewID) &&
+			(request.RequestID == "" || o.exchange.RequestId == request.RequestID) &&
+			(request.WalletID == 0 || o.walletID == request.WalletID) {
+			return o.exchange, nil
+		}
+	}
+	return nil, errorf(CodeOrderNotFound, "transfer not found")
+}
@@ -0,0 +1,306 @@
+// Package ceffutest provides an in-memory fake of the Ceffu open API for testing
+// code built on client.Client without network access.
+//
+// The fake verifies the open-apikey and signature headers of every request,
+// keeps wallets, balances, deposits, withdrawals and transfers in memory, and
+// lets tests move transactions through their statuses and inject faults:
+//
+//	srv := ceffutest.NewServer()
+//	defer srv.Close()
+//
+//	c, _ := srv.Client(client.Options{})
+//	walletID := srv.CreatePrimeWallet("prime")
+//	srv.SetBalance(walletID, "ETH", "ETH", types.MustParseAmount("10"))
+package ceffutest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+// Fault describes an error injected into the responses of the fake server.
+type Fault struct {
+	// Path restricts the fault to one endpoint, e.g. client.PathWithdrawal. Empty matches every endpoint.
+	Path string
+	// Times is the number of requests the fault applies to, 0 means every request until ClearFaults.
+	Times int
+	// Latency delays the response.
+	Latency time.Duration
+	// StatusCode responds with the HTTP status code instead of 200.
+	StatusCode int
+	// Code and Message respond with an API error instead of handling the request.
+	Code    string
+	Message string
+	// Body responds with the raw body, e.g. a malformed JSON document.
+	Body []byte
+	// CloseConnection closes the connection without responding.
+	CloseConnection bool
+}
+
+// Request is a request received by the fake server.
+type Request struct {
+	Method string
+	Path   string
+	APIKey string
+	// Payload is the signed payload: the query string of GET requests or the body of POST requests.
+	Payload string
+}
+
+type handlerFunc func(r *http.Request, payload []byte) (interface{}, error)
+
+// Server is a fake Ceffu open API server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Now returns the current time of the fake server, time.Now by default.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	apiKeys  map[string]*rsa.PublicKey
+	faults   []*Fault
+	requests []Request
+	handlers map[string]map[string]handlerFunc
+	state
+}
+
+// NewServer starts and returns a new fake server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		Now:     time.Now,
+		apiKeys: make(map[string]*rsa.PublicKey),
+		state:   newState(),
+	}
+	s.handlers = s.routes()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddAPIKey registers an API key and the public key used to verify its signatures.
+func (s *Server) AddAPIKey(apiKey string, publicKey *rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys[apiKey] = publicKey
+}
+
+// NewAPIKey generates and registers a new API key, and returns it with its secret
+// (a base64 encoded PKCS#1 RSA private key) as accepted by client.New.
+func (s *Server) NewAPIKey() (apiKey, apiKeySecret string, err error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	s.mu.Lock()
+	apiKey = s.nextID("KEY")
+	s.mu.Unlock()
+
+	s.AddAPIKey(apiKey, &privateKey.PublicKey)
+	return apiKey, base64.StdEncoding.EncodeToString(x509.MarshalPKCS1PrivateKey(privateKey)), nil
+}
+
+// Client returns a client with a new API key, connected to the fake server. The API codes
+// of the fake server are mapped to errors with ErrorCodes unless opts.ErrorCodes is set.
+func (s *Server) Client(opts client.Options) (client.Client, error) {
+	apiKey, apiKeySecret, err := s.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	if opts.ErrorCodes == nil {
+		opts.ErrorCodes = ErrorCodes()
+	}
+	opts.Domain = s.URL
+	if opts.HttpClient == nil {
+		opts.HttpClient = s.Server.Client()
+	}
+	return client.New(apiKey, apiKeySecret, opts)
+}
+
+// InjectFault adds a fault to the responses of the server.
+// Faults are applied in the order they were added.
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the requests received by the server so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	payload := []byte(r.URL.RawQuery)
+	if r.Method != http.MethodGet {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payload = body
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		APIKey:  r.Header.Get("open-apikey"),
+		Payload: string(payload),
+	})
+	fault := s.takeFault(r.URL.Path)
+	s.mu.Unlock()
+
+	if fault != nil && s.applyFault(w, r, fault) {
+		return
+	}
+
+	handler, ok := s.handlers[r.URL.Path][r.Method]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := s.authenticate(r, payload); err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	// encode the response while holding the lock, as it references the state of the server
+	s.mu.Lock()
+	data, err := handler(r, payload)
+	var raw json.RawMessage
+	if err == nil {
+		raw, err = json.Marshal(data)
+	}
+	s.mu.Unlock()
+	writeResponse(w, raw, err)
+}
+
+// takeFault returns the first fault matching the path. s.mu must be held.
+func (s *Server) takeFault(path string) *Fault {
+	for i, fault := range s.faults {
+		if fault.Path != "" && fault.Path != path {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// applyFault applies the fault and reports whether the request has been handled. The
+// latency is cut short if the request is cancelled, leaving the request unanswered.
+func (s *Server) applyFault(w http.ResponseWriter, r *http.Request, fault *Fault) bool {
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+			return true
+		case <-timer.C:
+		}
+	}
+	switch {
+	case fault.CloseConnection:
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	case fault.StatusCode != 0 && fault.StatusCode != http.StatusOK:
+		w.WriteHeader(fault.StatusCode)
+		w.Write(fault.Body)
+	case fault.Body != nil:
+		w.Write(fault.Body)
+	case fault.Code != "":
+		writeResponse(w, nil, &apiError{code: fault.Code, message: fault.Message})
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Server) authenticate(r *http.Request, payload []byte) error {
+	s.mu.Lock()
+	publicKey, ok := s.apiKeys[r.Header.Get("open-apikey")]
+	s.mu.Unlock()
+	if !ok {
+		return errorf(CodeInvalidAPIKey, "invalid api key")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(r.Header.Get("signature"))
+	if err != nil {
+		return errorf(CodeInvalidSignature, "invalid signature")
+	}
+	hashed := sha512.Sum512(payload)
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA512, hashed[:], signature); err != nil {
+		return errorf(CodeInvalidSignature, "invalid signature")
+	}
+	return nil
+}
+
+type response struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func writeResponse(w http.ResponseWriter, data interface{}, err error) {
+	resp := response{Code: client.SuccessCode, Message: "success", Data: data}
+	if err != nil {
+		resp = response{Code: CodeSystemError, Message: err.Error()}
+		if apiErr, ok := err.(*apiError); ok {
+			resp.Code = apiErr.code
+		}
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+type apiError struct {
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func errorf(code, message string) error {
+	return &apiError{code: code, message: message}
+}
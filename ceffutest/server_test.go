@@ -0,0 +1,440 @@
+package ceffutest_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+func newClient(t *testing.T, srv *ceffutest.Server, opts client.Options) client.Client {
+	t.Helper()
+	c, err := srv.Client(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSignatureRejected(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+
+	// the API key is registered with the public key of another private key
+	registered, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddAPIKey("key", &registered.PublicKey)
+	secret := base64.StdEncoding.EncodeToString(x509.MarshalPKCS1PrivateKey(other))
+
+	c, err := client.New("key", secret, client.Options{Domain: srv.URL, ErrorCodes: ceffutest.ErrorCodes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID)
+	if !errors.Is(err, ceffutest.ErrInvalidSignature) {
+		t.Fatalf("GetDepositAddress with a wrong key: got %v, want ErrInvalidSignature", err)
+	}
+
+	c, err = client.New("unknown", secret, client.Options{Domain: srv.URL, ErrorCodes: ceffutest.ErrorCodes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID)
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("GetDepositAddress with an unknown key: got %v, want ErrUnauthorized", err)
+	}
+
+	// the signature covers the payload: a signed query modified in transit is rejected
+	tampered := newClient(t, srv, client.Options{HttpClient: &http.Client{Transport: tamper{}}})
+	_, err = tampered.GetDepositAddress(context.Background(), "ETH", "ETH", walletID)
+	if !errors.Is(err, ceffutest.ErrInvalidSignature) {
+		t.Fatalf("GetDepositAddress with a tampered query: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+// tamper changes the page of the requests after they are signed.
+type tamper struct{}
+
+func (tamper) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	q := r.URL.Query()
+	q.Set("pageNo", "2")
+	r.URL.RawQuery = q.Encode()
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestFaults(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	ctx := context.Background()
+
+	retrying := newClient(t, srv, client.Options{RetryPolicy: &client.RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           time.Millisecond,
+		Multiplier:           1,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}})
+	srv.InjectFault(ceffutest.Fault{Path: client.PathGetDepositAddress, Times: 2, StatusCode: http.StatusServiceUnavailable})
+	before := len(srv.Requests())
+	if _, err := retrying.GetDepositAddress(ctx, "ETH", "ETH", walletID); err != nil {
+		t.Fatalf("GetDepositAddress after 2 faults: %v", err)
+	}
+	if n := len(srv.Requests()) - before; n != 3 {
+		t.Errorf("GetDepositAddress sent %d requests, want 3", n)
+	}
+
+	// net/http resends requests failing on a reused connection, which would hide the closed connections
+	c := newClient(t, srv, client.Options{HttpClient: &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}})
+	tests := []struct {
+		name  string
+		fault ceffutest.Fault
+		check func(err error) error
+	}{
+		{
+			name:  "status code",
+			fault: ceffutest.Fault{StatusCode: http.StatusBadGateway, Body: []byte("bad gateway")},
+			check: func(err error) error {
+				var requestErr *client.RequestError
+				if !errors.As(err, &requestErr) || requestErr.StatusCode != http.StatusBadGateway || requestErr.Code != "" {
+					return fmt.Errorf("got %#v, want a RequestError with status 502 and no API code", err)
+				}
+				if !errors.Is(err, client.ErrServer) {
+					return errors.New("does not match ErrServer")
+				}
+				return nil
+			},
+		},
+		{
+			name:  "api code",
+			fault: ceffutest.Fault{Code: ceffutest.CodeInsufficientBalance, Message: "insufficient balance"},
+			check: func(err error) error {
+				if !errors.Is(err, ceffutest.ErrInsufficientBalance) {
+					return fmt.Errorf("got %v, want ErrInsufficientBalance", err)
+				}
+				return nil
+			},
+		},
+		{
+			name:  "malformed body",
+			fault: ceffutest.Fault{Body: []byte("{")},
+			check: func(err error) error {
+				if err == nil {
+					return errors.New("got no error")
+				}
+				return nil
+			},
+		},
+		{
+			name:  "closed connection",
+			fault: ceffutest.Fault{CloseConnection: true},
+			check: func(err error) error {
+				if err == nil {
+					return errors.New("got no error")
+				}
+				return nil
+			},
+		},
+		{
+			name:  "latency",
+			fault: ceffutest.Fault{Latency: 200 * time.Millisecond},
+			check: func(err error) error {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					return fmt.Errorf("got %v, want context.DeadlineExceeded", err)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fault.Path = client.PathGetDepositAddress
+			tt.fault.Times = 1
+			srv.InjectFault(tt.fault)
+			defer srv.ClearFaults()
+
+			ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_, err := c.GetDepositAddress(ctx, "ETH", "ETH", walletID)
+			if err := tt.check(err); err != nil {
+				t.Error(err)
+			}
+			if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); err != nil {
+				t.Errorf("GetDepositAddress after the fault: %v", err)
+			}
+		})
+	}
+}
+
+func TestFaultLatencyCancelled(t *testing.T) {
+	srv := ceffutest.NewServer()
+	walletID := srv.CreatePrimeWallet("prime")
+	c := newClient(t, srv, client.Options{})
+	srv.InjectFault(ceffutest.Fault{Path: client.PathGetDepositAddress, Latency: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetDepositAddress(ctx, "ETH", "ETH", walletID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetDepositAddress = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Close waits for the handlers, which return once their request is cancelled
+	closed := make(chan struct{})
+	go func() {
+		srv.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler still waits for the latency of the cancelled request")
+	}
+}
+
+func TestWithdrawalStatus(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv, client.Options{})
+	ctx := context.Background()
+
+	walletID := srv.CreatePrimeWallet("prime")
+	if err := srv.SetBalance(walletID, "ETH", "ETH", types.MustParseAmount("10")); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetWithdrawalFee("ETH", "ETH", types.MustParseAmount("0.01"))
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+	request := func(amount string) *types.WithdrawalRequest {
+		return &types.WithdrawalRequest{
+			WalletID:          walletID,
+			CoinSymbol:        "ETH",
+			Network:           "ETH",
+			Amount:            types.MustParseAmount(amount),
+			WithdrawalAddress: address,
+		}
+	}
+
+	if _, err := c.Withdrawal(ctx, request("1")); !errors.Is(err, client.ErrAddressNotWhitelisted) {
+		t.Fatalf("Withdrawal to a new address: got %v, want ErrAddressNotWhitelisted", err)
+	}
+	if err := srv.WhitelistAddress(walletID, "ETH", address); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Withdrawal(ctx, request("100")); !errors.Is(err, ceffutest.ErrInsufficientBalance) {
+		t.Fatalf("Withdrawal over the balance: got %v, want ErrInsufficientBalance", err)
+	}
+
+	assertBalance := func(available, frozen string) {
+		t.Helper()
+		gotAvailable, gotFrozen, err := srv.Balance(walletID, "ETH", "ETH")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !gotAvailable.Equal(types.MustParseAmount(available)) || !gotFrozen.Equal(types.MustParseAmount(frozen)) {
+			t.Errorf("balance = %s available, %s frozen, want %s, %s", gotAvailable, gotFrozen, available, frozen)
+		}
+	}
+	assertStatus := func(orderViewID string, want int64) {
+		t.Helper()
+		tx, err := c.WithdrawalDetail(ctx, orderViewID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Status != want {
+			t.Errorf("status of %s = %d, want %d", orderViewID, tx.Status, want)
+		}
+	}
+
+	succeeded, err := c.Withdrawal(ctx, request("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(succeeded.OrderViewId, types.TransactionStatusPending)
+	assertBalance("8.99", "1.01")
+
+	for _, want := range []int64{types.TransactionStatusProcessing, types.TransactionStatusSuccess, types.TransactionStatusConfirmed} {
+		status, err := srv.Advance(succeeded.OrderViewId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != want {
+			t.Fatalf("Advance = %d, want %d", status, want)
+		}
+		assertStatus(succeeded.OrderViewId, want)
+	}
+	assertBalance("8.99", "0")
+	if _, err := srv.Advance(succeeded.OrderViewId); err == nil {
+		t.Error("Advance of a confirmed withdrawal succeeded")
+	}
+
+	failed, err := c.Withdrawal(ctx, request("2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBalance("6.98", "2.01")
+	if err := srv.SetStatus(failed.OrderViewId, types.TransactionStatusFailed); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(failed.OrderViewId, types.TransactionStatusFailed)
+	assertBalance("8.99", "0")
+	if err := srv.SetStatus(failed.OrderViewId, types.TransactionStatusSuccess); err == nil {
+		t.Error("SetStatus of a failed withdrawal succeeded")
+	}
+}
+
+func TestDepositStatus(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv, client.Options{})
+	ctx := context.Background()
+
+	walletID := srv.CreatePrimeWallet("prime")
+	orderViewID, err := srv.Deposit(walletID, "BTC", "BTC", types.MustParseAmount("0.5"), "bc1qsender")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []int64{types.TransactionStatusPending, types.TransactionStatusProcessing, types.TransactionStatusSuccess} {
+		if status != types.TransactionStatusPending {
+			if _, err := srv.Advance(orderViewID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		now := time.Now().UnixMilli()
+		deposits, err := c.GetDepositHistory(ctx, walletID, "BTC", "BTC", now-time.Hour.Milliseconds(), now, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deposits) != 1 || deposits[0].OrderViewID != orderViewID || deposits[0].Status != status {
+			t.Fatalf("deposit history = %+v, want %s with status %d", deposits, orderViewID, status)
+		}
+	}
+
+	available, _, err := srv.Balance(walletID, "BTC", "BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !available.Equal(types.MustParseAmount("0.5")) {
+		t.Errorf("available balance = %s, want 0.5 BTC", available)
+	}
+}
+
+func TestSubWallets(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv, client.Options{})
+	ctx := context.Background()
+	parentWalletID := srv.CreatePrimeWallet("prime")
+
+	walletID, walletType, err := c.CreateSubWallet(ctx, strconv.FormatInt(parentWalletID, 10), "sub", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walletID == parentWalletID || walletType == 0 {
+		t.Errorf("CreateSubWallet = %d, type %d", walletID, walletType)
+	}
+	if _, _, err := c.CreateSubWallet(ctx, strconv.FormatInt(walletID, 10), "nested", false); err == nil {
+		t.Error("CreateSubWallet under a sub wallet succeeded")
+	}
+
+	// the deposits of the sub wallet are listed with the prime wallet
+	orderViewID, err := srv.Deposit(walletID, "ETH", "ETH", types.MustParseAmount("1"), "0xsender")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UnixMilli()
+	for _, id := range []int64{walletID, parentWalletID} {
+		deposits, err := c.GetDepositHistory(ctx, id, "ETH", "ETH", now-time.Hour.Milliseconds(), now+time.Minute.Milliseconds(), 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deposits) != 1 || deposits[0].OrderViewID != orderViewID {
+			t.Errorf("deposits of wallet %d = %+v, want %s", id, deposits, orderViewID)
+		}
+	}
+}
+
+func TestPaging(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv, client.Options{})
+	ctx := context.Background()
+
+	parentWalletID := srv.CreatePrimeWallet("prime")
+
+	// the iterator walks every page
+	for i := 0; i < 23; i++ {
+		if _, err := srv.Deposit(parentWalletID, "ETH", "ETH", types.NewAmount(int64(i+1), 0), "0xsender"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UnixMilli()
+	it := client.NewDepositHistoryIterator(c, parentWalletID, "ETH", "ETH", now-time.Hour.Milliseconds(), now+time.Minute.Milliseconds(), 5)
+	n := 0
+	for {
+		_, err := it.Next(ctx)
+		if errors.Is(err, client.Done) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 23 {
+		t.Errorf("iterator returned %d deposits, want 23", n)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	request := &types.WithdrawalRequest{
+		WalletID:          walletID,
+		CoinSymbol:        "ETH",
+		Network:           "ETH",
+		Amount:            types.MustParseAmount("1"),
+		WithdrawalAddress: "0x52908400098527886E0F7030069857D2E4169EE7",
+	}
+
+	// the codes of the fake are mapped per client, not for every client of the process
+	apiKey, secret, err := srv.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := client.New(apiKey, secret, client.Options{Domain: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = plain.Withdrawal(context.Background(), request)
+	var requestErr *client.RequestError
+	if !errors.As(err, &requestErr) || requestErr.Code != ceffutest.CodeAddressNotWhitelisted {
+		t.Fatalf("Withdrawal to a new address: got %v", err)
+	}
+	if errors.Is(err, client.ErrAddressNotWhitelisted) {
+		t.Error("client without ErrorCodes matches ErrAddressNotWhitelisted")
+	}
+
+	c := newClient(t, srv, client.Options{})
+	if _, err := c.Withdrawal(context.Background(), request); !errors.Is(err, client.ErrAddressNotWhitelisted) {
+		t.Errorf("Withdrawal to a new address: got %v, want ErrAddressNotWhitelisted", err)
+	}
+}
@@ -0,0 +1,411 @@
+package ceffutest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+const (
+	walletTypePrime = 10
+	walletTypeSub   = 20
+)
+
+type assetKey struct {
+	symbol  string
+	network string
+}
+
+type balance struct {
+	available types.Amount
+	frozen    types.Amount
+}
+
+type wallet struct {
+	info      types.SubWalletInfo
+	balances  map[assetKey]*balance
+	whitelist []whitelistEntry
+}
+
+// whitelistEntry is an address withdrawals of every coin of a network can be sent to.
+type whitelistEntry struct {
+	network string
+	address string
+}
+
+func (w *wallet) isSub() bool {
+	return w.info.ParentWalletId != 0
+}
+
+// whitelisted reports whether withdrawals on the network can be sent to the address.
+func (w *wallet) whitelisted(network, address string) bool {
+	for _, a := range w.whitelist {
+		if a.network == network && a.address == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *wallet) balance(symbol, network string) *balance {
+	key := assetKey{symbol: symbol, network: network}
+	b, ok := w.balances[key]
+	if !ok {
+		b = &balance{}
+		w.balances[key] = b
+	}
+	return b
+}
+
+// order is a deposit, withdrawal or exchange transfer, moving through the TransactionStatus* states.
+type order struct {
+	walletID   int64
+	toWalletID int64 // credited on success, for withdrawals to a Ceffu wallet
+	symbol     string
+	network    string
+	frozen     types.Amount // amount frozen until the order succeeds or fails
+	createdAt  int64
+	settled    bool
+
+	tx       *types.Transaction    // deposits and withdrawals
+	exchange *types.TransferDetail // exchange transfers
+}
+
+func (o *order) status() int64 {
+	if o.exchange != nil {
+		return int64(o.exchange.Status)
+	}
+	return o.tx.Status
+}
+
+func (o *order) setStatus(status int64) {
+	if o.exchange != nil {
+		o.exchange.Status = int32(status)
+		return
+	}
+	o.tx.Status = status
+}
+
+// transfer is a transfer between wallets of the account, completed when it is requested.
+type transfer struct {
+	orderViewID  string
+	symbol       string
+	amount       types.Amount
+	fromWalletID int64
+	toWalletID   int64
+	direction    int32
+	requestID    string
+	createdAt    int64
+}
+
+type state struct {
+	seq               int64
+	wallets           map[int64]*wallet
+	walletIDs         []int64
+	orders            map[string]*order
+	deposits          []*order
+	withdrawals       []*order
+	exchangeTransfers []*order
+	transfers         []*transfer
+	requestIDs        map[string]map[string]bool
+	withdrawalFees    map[assetKey]types.Amount
+	coinNetworks      map[assetKey]*client.CoinNetwork
+	location          *time.Location
+}
+
+func newState() state {
+	return state{
+		seq:            1000000,
+		wallets:        make(map[int64]*wallet),
+		orders:         make(map[string]*order),
+		requestIDs:     make(map[string]map[string]bool),
+		withdrawalFees: make(map[assetKey]types.Amount),
+		coinNetworks:   make(map[assetKey]*client.CoinNetwork),
+		location:       time.UTC,
+	}
+}
+
+func (s *state) nextID(prefix string) string {
+	s.seq++
+	return prefix + strconv.FormatInt(s.seq, 10)
+}
+
+// useRequestID records the request id of a POST request, and fails if it was already used.
+func (s *state) useRequestID(path, requestID string) error {
+	if requestID == "" {
+		return errorf(CodeInvalidParameter, "requestId is required")
+	}
+	if s.requestIDs[path] == nil {
+		s.requestIDs[path] = make(map[string]bool)
+	}
+	if s.requestIDs[path][requestID] {
+		return errorf(CodeDuplicateRequestID, "duplicate requestId")
+	}
+	s.requestIDs[path][requestID] = true
+	return nil
+}
+
+func (s *state) createWallet(name string, parentWalletID int64) *wallet {
+	s.seq++
+	w := &wallet{
+		info: types.SubWalletInfo{
+			WalletId:    s.seq,
+			WalletIdStr: strconv.FormatInt(s.seq, 10),
+			WalletName:  name,
+			WalletType:  walletTypePrime,
+		},
+		balances: make(map[assetKey]*balance),
+	}
+	if parentWalletID != 0 {
+		w.info.WalletType = walletTypeSub
+		w.info.ParentWalletId = parentWalletID
+		w.info.ParentWalletIdStr = strconv.FormatInt(parentWalletID, 10)
+	}
+	s.wallets[w.info.WalletId] = w
+	s.walletIDs = append(s.walletIDs, w.info.WalletId)
+	return w
+}
+
+func (s *state) wallet(walletID int64) (*wallet, error) {
+	w, ok := s.wallets[walletID]
+	if !ok {
+		return nil, errorf(CodeWalletNotFound, "wallet not found")
+	}
+	return w, nil
+}
+
+// belongsTo reports whether walletID is the requested wallet or one of its sub wallets.
+func (s *state) belongsTo(walletID, requested int64) bool {
+	if walletID == requested {
+		return true
+	}
+	w, ok := s.wallets[walletID]
+	return ok && w.info.ParentWalletId == requested
+}
+
+// updateStatus moves an order to the status and applies its effects on balances.
+func (s *state) updateStatus(o *order, status int64) error {
+	current := o.status()
+	if current == types.TransactionStatusConfirmed || current == types.TransactionStatusFailed {
+		return fmt.Errorf("order is already in final status %d", current)
+	}
+	if status != types.TransactionStatusFailed && status < current {
+		return fmt.Errorf("order cannot move from status %d to %d", current, status)
+	}
+	o.setStatus(status)
+
+	if o.settled {
+		return nil
+	}
+	switch {
+	case status == types.TransactionStatusFailed:
+		// unfreeze
+		b := s.wallets[o.walletID].balance(o.symbol, o.network)
+		b.frozen = b.frozen.Sub(o.frozen)
+		if o.tx == nil || o.tx.Direction != types.TransactionDirectionDeposit {
+			b.available = b.available.Add(o.frozen)
+		}
+		o.settled = true
+	case status >= types.TransactionStatusSuccess:
+		if o.tx != nil && o.tx.Direction == types.TransactionDirectionDeposit {
+			b := s.wallets[o.walletID].balance(o.symbol, o.network)
+			b.available = b.available.Add(o.tx.Amount)
+		} else {
+			b := s.wallets[o.walletID].balance(o.symbol, o.network)
+			b.frozen = b.frozen.Sub(o.frozen)
+		}
+		if to, ok := s.wallets[o.toWalletID]; ok {
+			b := to.balance(o.symbol, o.network)
+			b.available = b.available.Add(o.tx.Amount)
+		}
+		o.settled = true
+	}
+	return nil
+}
+
+func (s *state) newTransaction(walletID int64, direction int64, symbol, network string, amount types.Amount, createdAt int64) *order {
+	o := &order{
+		walletID:  walletID,
+		symbol:    symbol,
+		network:   network,
+		createdAt: createdAt,
+		tx: &types.Transaction{
+			OrderViewID:  s.nextID(""),
+			TransferType: types.TransferTypeOnChain,
+			Direction:    direction,
+			Network:      network,
+			CoinSymbol:   symbol,
+			Amount:       amount,
+			FeeSymbol:    symbol,
+			Status:       types.TransactionStatusPending,
+			TxTime:       s.formatTime(createdAt),
+			WalletStr:    strconv.FormatInt(walletID, 10),
+		},
+	}
+	s.orders[o.tx.OrderViewID] = o
+	return o
+}
+
+func (s *state) depositAddress(walletID int64, symbol, network string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%s/%s", walletID, symbol, network)))
+	return "0x" + hex.EncodeToString(sum[:20])
+}
+
+// CreatePrimeWallet creates a prime wallet and returns its wallet id.
+func (s *Server) CreatePrimeWallet(name string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createWallet(name, 0).info.WalletId
+}
+
+// SetBalance sets the available balance of a wallet.
+func (s *Server) SetBalance(walletID int64, symbol, network string, amount types.Amount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.wallet(walletID)
+	if err != nil {
+		return err
+	}
+	w.balance(symbol, network).available = amount
+	return nil
+}
+
+// Balance returns the available and frozen balance of a wallet.
+func (s *Server) Balance(walletID int64, symbol, network string) (available, frozen types.Amount, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.wallet(walletID)
+	if err != nil {
+		return types.Amount{}, types.Amount{}, err
+	}
+	b := w.balance(symbol, network)
+	return b.available, b.frozen, nil
+}
+
+// SetLocation sets the time zone the transaction times (txTime) are formatted in, UTC by default.
+func (s *Server) SetLocation(location *time.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.location = location
+}
+
+// SetWithdrawalFee sets the network fee charged on withdrawals of the coin on the network.
+func (s *Server) SetWithdrawalFee(symbol, network string, fee types.Amount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.withdrawalFees[assetKey{symbol: symbol, network: network}] = fee
+}
+
+// SetCoinNetwork sets the withdrawal rules of a coin on a network, enforced on
+// withdrawals. Its WithdrawFee is the withdrawal fee, see SetWithdrawalFee.
+func (s *Server) SetCoinNetwork(network client.CoinNetwork) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := assetKey{symbol: network.CoinSymbol, network: network.Network}
+	s.coinNetworks[key] = &network
+	s.withdrawalFees[key] = network.WithdrawFee
+}
+
+// WhitelistAddress adds a withdrawal address to the whitelist of a wallet, for every coin of the
+// network. Withdrawals to other addresses are rejected with CodeAddressNotWhitelisted.
+func (s *Server) WhitelistAddress(walletID int64, network, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.wallet(walletID)
+	if err != nil {
+		return err
+	}
+	w.whitelist = append(w.whitelist, whitelistEntry{network: network, address: address})
+	return nil
+}
+
+// Deposit simulates an incoming on-chain deposit to a wallet and returns its orderViewId.
+// The deposit is pending, and credited to the wallet when it reaches TransactionStatusSuccess.
+func (s *Server) Deposit(walletID int64, symbol, network string, amount types.Amount, fromAddress string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.wallet(walletID); err != nil {
+		return "", err
+	}
+	o := s.newTransaction(walletID, types.TransactionDirectionDeposit, symbol, network, amount, s.Now().UnixMilli())
+	o.tx.TxID = s.txHash(o.tx.OrderViewID)
+	o.tx.FromAddress = fromAddress
+	o.tx.ToAddress = s.depositAddress(walletID, symbol, network)
+	s.deposits = append(s.deposits, o)
+	return o.tx.OrderViewID, nil
+}
+
+// SetStatus moves a deposit, withdrawal or exchange transfer to one of the TransactionStatus* states.
+// Deposits are credited and withdrawals debited when they reach TransactionStatusSuccess, and
+// frozen amounts are released when they reach TransactionStatusFailed.
+func (s *Server) SetStatus(orderViewID string, status int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderViewID]
+	if !ok {
+		return fmt.Errorf("order %s not found", orderViewID)
+	}
+	return s.updateStatus(o, status)
+}
+
+// Advance moves a deposit, withdrawal or exchange transfer to its next state:
+// Pending, Processing, Success and Confirmed. It returns the new status.
+func (s *Server) Advance(orderViewID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderViewID]
+	if !ok {
+		return 0, fmt.Errorf("order %s not found", orderViewID)
+	}
+	next := map[int64]int64{
+		types.TransactionStatusPending:    types.TransactionStatusProcessing,
+		types.TransactionStatusProcessing: types.TransactionStatusSuccess,
+		types.TransactionStatusSuccess:    types.TransactionStatusConfirmed,
+	}[o.status()]
+	if next == 0 {
+		return 0, fmt.Errorf("order %s is already in final status %d", orderViewID, o.status())
+	}
+	if err := s.updateStatus(o, next); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+func (s *Server) txHash(orderViewID string) string {
+	sum := sha256.Sum256([]byte(orderViewID))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+func (s *state) formatTime(ms int64) string {
+	return time.UnixMilli(ms).In(s.location).Format("2006-01-02 15:04:05")
+}
+
+func sortedKeys(balances map[assetKey]*balance) []assetKey {
+	keys := make([]assetKey, 0, len(balances))
+	for key := range balances {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].symbol != keys[j].symbol {
+			return keys[i].symbol < keys[j].symbol
+		}
+		return keys[i].network < keys[j].network
+	})
+	return keys
+}
@@ -0,0 +1,283 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// AddressValidator validates the format of an address on a network. The memo/address
+// tag is not validated, the networks requiring one are set with Options.CoinNetworks.
+type AddressValidator func(address string) error
+
+var addressValidators = map[string]AddressValidator{
+	"ETH":      validateEVMAddress,
+	"BSC":      validateEVMAddress,
+	"ARBITRUM": validateEVMAddress,
+	"OPTIMISM": validateEVMAddress,
+	"POLYGON":  validateEVMAddress,
+	"MATIC":    validateEVMAddress,
+	"AVAXC":    validateEVMAddress,
+	"BASE":     validateEVMAddress,
+	"BTC":      validateBTCAddress,
+	"TRX":      validateTRONAddress,
+	"TRON":     validateTRONAddress,
+	"XRP":      validateXRPAddress,
+	"EOS":      validateEOSAddress,
+}
+
+// ValidateAddress validates the format of a withdrawal address on a network:
+//   - EVM networks (ETH, BSC, ...): 0x followed by 40 hex digits, with a valid EIP-55
+//     checksum if the address is mixed-case,
+//   - BTC: a bech32 (SegWit v0) or bech32m (Taproot) address, or a legacy base58 address,
+//   - TRX: a base58check address starting with T,
+//   - XRP: a base58 classic address,
+//   - EOS: an account name.
+//
+// The addresses of other networks are not validated, see Options.AddressValidators.
+func ValidateAddress(network, address string) error {
+	return validateAddress(addressValidators, network, address)
+}
+
+// validateAddress validates an address with the validator of its network in validators.
+func validateAddress(validators map[string]AddressValidator, network, address string) error {
+	if address == "" {
+		return errors.New("address is empty")
+	}
+	validator := validators[strings.ToUpper(network)]
+	if validator == nil {
+		return nil
+	}
+	return validator(address)
+}
+
+func validateEVMAddress(address string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return errors.New("EVM address must be 0x followed by 40 hex digits")
+	}
+	hexAddress := address[2:]
+	if _, err := hex.DecodeString(hexAddress); err != nil {
+		return errors.New("EVM address must be 0x followed by 40 hex digits")
+	}
+	if hexAddress == strings.ToLower(hexAddress) || hexAddress == strings.ToUpper(hexAddress) {
+		// not checksummed
+		return nil
+	}
+
+	// EIP-55: a letter is uppercase if the matching nibble of the hash is at least 8
+	lower := strings.ToLower(hexAddress)
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	digest := hash.Sum(nil)
+	for i, c := range lower {
+		nibble := digest[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		expected := c
+		if c >= 'a' && nibble&0xf >= 8 {
+			expected = c - 'a' + 'A'
+		}
+		if rune(hexAddress[i]) != expected {
+			return errors.New("invalid EIP-55 checksum")
+		}
+	}
+	return nil
+}
+
+func validateBTCAddress(address string) error {
+	if strings.HasPrefix(strings.ToLower(address), "bc1") {
+		return validateSegWitAddress("bc", address)
+	}
+	payload, err := decodeBase58Check(address, bitcoinAlphabet)
+	if err != nil {
+		return err
+	}
+	// P2PKH or P2SH
+	if len(payload) != 21 || (payload[0] != 0x00 && payload[0] != 0x05) {
+		return errors.New("invalid BTC address version")
+	}
+	return nil
+}
+
+func validateTRONAddress(address string) error {
+	if !strings.HasPrefix(address, "T") {
+		return errors.New("TRON address must start with T")
+	}
+	payload, err := decodeBase58Check(address, bitcoinAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || payload[0] != 0x41 {
+		return errors.New("invalid TRON address")
+	}
+	return nil
+}
+
+func validateXRPAddress(address string) error {
+	if !strings.HasPrefix(address, "r") {
+		return errors.New("XRP address must start with r")
+	}
+	payload, err := decodeBase58Check(address, rippleAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || payload[0] != 0x00 {
+		return errors.New("invalid XRP address")
+	}
+	return nil
+}
+
+func validateEOSAddress(address string) error {
+	if len(address) > 12 {
+		return errors.New("EOS account name must be at most 12 characters")
+	}
+	for _, c := range address {
+		if !(c >= 'a' && c <= 'z' || c >= '1' && c <= '5' || c == '.') {
+			return errors.New("EOS account name must only contain a-z, 1-5 and .")
+		}
+	}
+	return nil
+}
+
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+)
+
+// decodeBase58Check decodes a base58 string and verifies its 4 bytes double SHA256
+// checksum. It returns the payload without the checksum.
+func decodeBase58Check(s, alphabet string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		digit := strings.IndexRune(alphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	decoded := n.Bytes()
+	// leading zero bytes are encoded as leading first characters of the alphabet
+	for i := 0; i < len(s) && s[i] == alphabet[0]; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) < 5 {
+		return nil, errors.New("base58 address is too short")
+	}
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("invalid base58 checksum")
+	}
+	return payload, nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Constant  = 1
+	bech32mConstant = 0x2bc830a3
+)
+
+// validateSegWitAddress validates a SegWit address, see BIP 173 and BIP 350.
+func validateSegWitAddress(hrp, address string) error {
+	if len(address) > 90 {
+		return errors.New("bech32 address is too long")
+	}
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return errors.New("bech32 address must not be mixed-case")
+	}
+	address = strings.ToLower(address)
+	separator := strings.LastIndexByte(address, '1')
+	if separator < 1 || separator+7 > len(address) || address[:separator] != hrp {
+		return errors.New("invalid bech32 address")
+	}
+
+	data := make([]byte, 0, len(address)-separator-1)
+	for _, c := range address[separator+1:] {
+		value := strings.IndexRune(bech32Charset, c)
+		if value < 0 {
+			return fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(value))
+	}
+	constant := bech32Polymod(append(bech32ExpandHRP(hrp), data...))
+	if constant != bech32Constant && constant != bech32mConstant {
+		return errors.New("invalid bech32 checksum")
+	}
+
+	data = data[:len(data)-6]
+	if len(data) == 0 {
+		return errors.New("invalid bech32 address")
+	}
+	version := data[0]
+	if version > 16 {
+		return errors.New("invalid witness version")
+	}
+	// SegWit v0 uses bech32, later versions bech32m
+	if (version == 0) != (constant == bech32Constant) {
+		return errors.New("invalid bech32 checksum variant for the witness version")
+	}
+	program, err := convertBits(data[1:], 5, 8)
+	if err != nil {
+		return err
+	}
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return errors.New("invalid witness program length")
+	}
+	return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups bits, without padding, as for the witness program of SegWit addresses.
+func convertBits(data []byte, from, to uint) ([]byte, error) {
+	var acc, bits uint
+	out := make([]byte, 0, len(data)*int(from)/int(to))
+	for _, value := range data {
+		acc = acc<<from | uint(value)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&(1<<to-1)))
+		}
+	}
+	if bits >= from || acc<<(to-bits)&(1<<to-1) != 0 {
+		return nil, errors.New("invalid witness program padding")
+	}
+	return out, nil
+}
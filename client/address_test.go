@@ -0,0 +1,67 @@
+package client
+
+import "testing"
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		network string
+		address string
+		valid   bool
+	}{
+		// EIP-55 test vectors
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"ETH", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", true},
+		{"BSC", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", true},
+		{"eth", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", true},
+		{"ETH", "0x52908400098527886E0F7030069857D2E4169EE7", true},
+		{"ETH", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"ETH", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", true},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe", false},
+		{"ETH", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", false},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", false},
+		{"ETH", "", false},
+
+		// BIP-173 and BIP-350 test vectors
+		{"BTC", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true},
+		{"BTC", "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", true},
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", true},
+		{"BTC", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", true},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", false},                     // invalid checksum
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", false},                     // bech32m for witness version 0
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", false}, // bech32 for witness version 1
+		{"BTC", "BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", false},                           // invalid program length for witness version 0
+		{"BTC", "bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", false},                     // mixed case
+		{"BTC", "bc1gmk9yu", false},                                                      // empty data
+		{"BTC", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", false},                     // testnet
+		{"BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", true},                              // P2PKH
+		{"BTC", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true},                              // P2SH
+		{"BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", false},                             // invalid checksum
+		{"BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN0", false},                             // not base58
+		{"BTC", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", false},                             // TRON version
+
+		{"TRX", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", true},
+		{"TRON", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", true},
+		{"TRX", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", false},
+		{"TRX", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", false},
+		{"TRX", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj", false},
+
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", true},
+		{"XRP", "rrrrrrrrrrrrrrrrrrrrrhoLvTp", true},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTi", false},
+		{"XRP", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", false},
+
+		{"EOS", "eosio.token", true},
+		{"EOS", "EOSIO", false},
+		{"EOS", "eosio.tokens1", false},
+
+		// not validated
+		{"SOL", "not checked", true},
+	}
+	for _, tt := range tests {
+		err := ValidateAddress(tt.network, tt.address)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateAddress(%s, %q) = %v, want valid %v", tt.network, tt.address, err, tt.valid)
+		}
+	}
+}
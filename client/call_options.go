@@ -0,0 +1,49 @@
+package client
+
+import (
+	"errors"
+	"strconv"
+)
+
+// MaxRequestIDLength is the maximum length of a client provided request id.
+const MaxRequestIDLength = 70
+
+// ErrInvalidRequestID is returned when a request id provided with WithRequestID is
+// empty or longer than MaxRequestIDLength.
+var ErrInvalidRequestID = errors.New("request id must be 1 to 70 characters")
+
+type callOptions struct {
+	requestID *string
+}
+
+type CallOption func(*callOptions)
+
+// WithRequestID sets the client request identifier sent with the request instead of
+// generating one. Reusing the request id of a previous call makes the call idempotent:
+// Ceffu rejects a second request with the same id, and the result can be looked up
+// later with e.g. WithdrawalDetailByRequestID.
+func WithRequestID(requestID string) CallOption {
+	return func(o *callOptions) {
+		o.requestID = &requestID
+	}
+}
+
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// requestID returns the request id provided with WithRequestID, or generates a new one.
+func (c *client) requestID(opts []CallOption) (string, error) {
+	o := newCallOptions(opts)
+	if o.requestID == nil {
+		return strconv.FormatInt(c.RequestID.Generate(), 10), nil
+	}
+	if len(*o.requestID) == 0 || len(*o.requestID) > MaxRequestIDLength {
+		return "", ErrInvalidRequestID
+	}
+	return *o.requestID, nil
+}
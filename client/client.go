@@ -1,10 +1,8 @@
 package client
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/base64"
 	"net/http"
+	"strings"
 )
 
 type Client interface {
@@ -13,52 +11,134 @@ type Client interface {
 }
 
 type client struct {
-	domain     string
-	apiKey     string
-	privateKey *rsa.PrivateKey
-	httpClient *http.Client
-	RequestID  RequestID
+	domain       string
+	credentials  CredentialsProvider
+	httpClient   *http.Client
+	retryPolicy  *RetryPolicy
+	rateLimiter  *rateLimiter
+	roundTripper RoundTripper
+	logger       Logger
+	logLevels    LogLevels
+	redaction    Redaction
+	errorCodes   map[string]error
+	metrics      Metrics
+	RequestID    RequestID
+
+	validateWithdrawals bool
+	whitelistChecker    WhitelistChecker
+	coinNetworks        map[coinNetworkKey]CoinNetwork
+	addressValidators   map[string]AddressValidator
 }
 
 type Options struct {
-	Domain     string
+	Domain string
+	// HttpClient sends the requests, an http.Client with a 20 seconds timeout if nil.
 	HttpClient *http.Client
 	RequestID  RequestID
+	// Signer signs the requests instead of the API key secret, which may then be empty.
+	// See NewCryptoSigner for keys held in an HSM or a KMS.
+	Signer Signer
+	// Credentials provides the API key and the Signer for every request instead of apiKey,
+	// the API key secret and Signer, which may then be empty. It allows rotating the
+	// credentials without creating a new client, see RotatingCredentials and FileCredentials.
+	Credentials CredentialsProvider
+	// RetryPolicy configures retries of failed requests, nil disables retries.
+	// See DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy
+	// RateLimits paces the requests per path constant, e.g. PathWithdrawal. The limit of
+	// DefaultRateLimitPath applies to every other path. nil disables rate limiting, set the
+	// limits granted to the API key to enable it.
+	RateLimits map[string]RateLimit
+	// Middlewares wrap every operation, the first one being the outermost.
+	Middlewares []Middleware
+	// Logger logs every request and retry, nil disables logging. A *slog.Logger can be used.
+	Logger Logger
+	// LogLevels are the levels the operations are logged at, see DefaultLogLevels.
+	LogLevels LogLevels
+	// Redaction is how addresses, API keys and signatures are hidden from the logs and
+	// the messages of the errors, RedactPartial by default.
+	Redaction Redaction
+	// ErrorCodes maps API response codes to errors, e.g. ErrRateLimited or errors of the
+	// caller, so that the *RequestError with such a code matches them with errors.Is.
+	// Ceffu does not publish its codes, see ErrUnauthorized.
+	ErrorCodes map[string]error
+	// Metrics receives the measures of every request, nil disables them.
+	Metrics Metrics
+	// ValidateWithdrawals makes Withdrawal check the withdrawals with ValidateWithdrawal
+	// before sending them.
+	ValidateWithdrawals bool
+	// CoinNetworks are the withdrawal rules ValidateWithdrawal checks, e.g. as shown in the
+	// Ceffu Web Portal. The withdrawals of other coins and networks are not checked against
+	// any rule.
+	CoinNetworks []CoinNetwork
+	// AddressValidators sets the validators of the addresses of networks for
+	// ValidateWithdrawal, in addition to or instead of those of ValidateAddress, e.g. to
+	// support another network. A nil validator disables the validation of a network.
+	AddressValidators map[string]AddressValidator
+	// WhitelistChecker is consulted by ValidateWithdrawal for withdrawals to an address.
+	// If nil, the whitelist is not checked before sending the withdrawals.
+	WhitelistChecker WhitelistChecker
 }
 
 func New(apiKey, apiKeySecret string, opts Options) (Client, error) {
-	privateKey, err := parseRSAPrivateKey(apiKeySecret)
-	if err != nil {
-		return nil, err
+	if opts.Credentials == nil {
+		if opts.Signer == nil {
+			privateKey, err := ParsePrivateKey([]byte(apiKeySecret), nil)
+			if err != nil {
+				return nil, err
+			}
+			opts.Signer = NewRSASigner(privateKey)
+		}
+		opts.Credentials = NewRotatingCredentials(&Credentials{APIKey: apiKey, Signer: opts.Signer})
 	}
 
 	if opts.Domain == "" {
 		opts.Domain = DefaultDomain
 	}
 	if opts.HttpClient == nil {
-		opts.HttpClient = http.DefaultClient
+		opts.HttpClient = &http.Client{Timeout: defaultHTTPTimeout}
 	}
 	if opts.RequestID == nil {
 		opts.RequestID = NewRequestID()
 	}
 	c := &client{
-		domain:     opts.Domain,
-		apiKey:     apiKey,
-		privateKey: privateKey,
-		httpClient: opts.HttpClient,
-		RequestID:  opts.RequestID,
+		domain:      opts.Domain,
+		credentials: opts.Credentials,
+		httpClient:  opts.HttpClient,
+		retryPolicy: opts.RetryPolicy,
+		rateLimiter: newRateLimiter(opts.RateLimits, opts.ErrorCodes),
+		RequestID:   opts.RequestID,
+
+		logLevels:  opts.LogLevels.withDefaults(),
+		redaction:  opts.Redaction,
+		errorCodes: opts.ErrorCodes,
+
+		validateWithdrawals: opts.ValidateWithdrawals,
+		whitelistChecker:    opts.WhitelistChecker,
+		coinNetworks:        make(map[coinNetworkKey]CoinNetwork, len(opts.CoinNetworks)),
 	}
-	return c, nil
-}
+	c.addressValidators = make(map[string]AddressValidator, len(addressValidators)+len(opts.AddressValidators))
+	for network, validator := range addressValidators {
+		c.addressValidators[network] = validator
+	}
+	for network, validator := range opts.AddressValidators {
+		c.addressValidators[strings.ToUpper(network)] = validator
+	}
+	for _, network := range opts.CoinNetworks {
+		c.coinNetworks[coinNetworkKey{network.CoinSymbol, network.Network}] = network
+	}
+	c.metrics = nopMetrics{}
 
-func parseRSAPrivateKey(privateKeyBase64 string) (*rsa.PrivateKey, error) {
-	decodedPrivateKey, err := base64.StdEncoding.DecodeString(privateKeyBase64)
-	if err != nil {
-		return nil, err
+	// the built-in middlewares observe the requests actually sent, after the others
+	middlewares := append([]Middleware{}, opts.Middlewares...)
+	if opts.Logger != nil {
+		c.logger = opts.Logger
+		middlewares = append(middlewares, logging(opts.Logger, c.logLevels, opts.Redaction))
 	}
-	privateKey, err := x509.ParsePKCS1PrivateKey(decodedPrivateKey)
-	if err != nil {
-		return nil, err
+	if opts.Metrics != nil {
+		c.metrics = opts.Metrics
+		middlewares = append(middlewares, measuring(opts.Metrics))
 	}
-	return privateKey, nil
+	c.roundTripper = chain(RoundTripperFunc(c.call), middlewares)
+	return c, nil
 }
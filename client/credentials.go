@@ -0,0 +1,189 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWatchInterval is the interval FileCredentials.Watch checks the files at by default.
+const DefaultWatchInterval = 10 * time.Second
+
+// ErrInvalidCredentials is returned by the requests when the CredentialsProvider returns
+// no credentials, or credentials without API key or Signer.
+var ErrInvalidCredentials = errors.New("ceffu: invalid credentials")
+
+// maxReadAttempts is how many times FileCredentials reads the files to get a consistent
+// snapshot of both.
+const maxReadAttempts = 5
+
+// Credentials are the API key sent in the open-apikey header and the Signer of its private key.
+type Credentials struct {
+	APIKey string
+	Signer Signer
+}
+
+func (c *Credentials) validate() error {
+	switch {
+	case c == nil:
+		return fmt.Errorf("%w: no credentials", ErrInvalidCredentials)
+	case c.APIKey == "":
+		return fmt.Errorf("%w: no API key", ErrInvalidCredentials)
+	case c.Signer == nil:
+		return fmt.Errorf("%w: no signer", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// CredentialsProvider provides the credentials of the client. It is consulted before every
+// attempt of a request, so that rotated credentials are used by the next requests while
+// the requests in flight complete with the credentials they were signed with.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+// CredentialsFunc adapts a function to a CredentialsProvider, e.g. to fetch the
+// credentials from a secret manager. It is called before every attempt, and should cache.
+type CredentialsFunc func(ctx context.Context) (*Credentials, error)
+
+func (f CredentialsFunc) Credentials(ctx context.Context) (*Credentials, error) {
+	return f(ctx)
+}
+
+// RotatingCredentials are credentials that can be replaced atomically with Set while in use.
+type RotatingCredentials struct {
+	value atomic.Value
+}
+
+func NewRotatingCredentials(credentials *Credentials) *RotatingCredentials {
+	r := &RotatingCredentials{}
+	r.Set(credentials)
+	return r
+}
+
+func (r *RotatingCredentials) Credentials(_ context.Context) (*Credentials, error) {
+	credentials, _ := r.value.Load().(*Credentials)
+	if credentials == nil {
+		return nil, fmt.Errorf("%w: credentials are not set", ErrInvalidCredentials)
+	}
+	return credentials, nil
+}
+
+// Set replaces the credentials. Requests already signed keep the previous credentials.
+func (r *RotatingCredentials) Set(credentials *Credentials) {
+	r.value.Store(credentials)
+}
+
+// FileCredentials are the credentials stored in two files, the API key and its private key
+// in any of the formats of ParsePrivateKey, e.g. as mounted from a Kubernetes secret.
+// Watch reloads them when the files change.
+//
+// Both files are read until two reads in a row return the same content, so that an API key
+// is not paired with the private key of another when the files are replaced atomically
+// while they are read, as Kubernetes does. Files replaced one after the other are paired
+// wrongly until the second one is replaced: write them to a new directory and rename it.
+type FileCredentials struct {
+	RotatingCredentials
+	apiKeyPath     string
+	privateKeyPath string
+	passphrase     []byte
+
+	mu     sync.Mutex
+	apiKey []byte
+	key    []byte
+}
+
+// NewFileCredentials loads the credentials from the files. passphrase is only used for
+// encrypted private keys.
+func NewFileCredentials(apiKeyPath, privateKeyPath string, passphrase []byte) (*FileCredentials, error) {
+	f := &FileCredentials{
+		apiKeyPath:     apiKeyPath,
+		privateKeyPath: privateKeyPath,
+		passphrase:     passphrase,
+	}
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload loads the files again, and reports whether the credentials changed. The current
+// credentials are kept if the files are invalid, e.g. while they are being replaced.
+func (f *FileCredentials) Reload() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	apiKey, key, err := f.read()
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(apiKey, f.apiKey) && bytes.Equal(key, f.key) {
+		return false, nil
+	}
+
+	privateKey, err := ParsePrivateKey(key, f.passphrase)
+	if err != nil {
+		return false, err
+	}
+	credentials := &Credentials{
+		APIKey: strings.TrimSpace(string(apiKey)),
+		Signer: NewRSASigner(privateKey),
+	}
+	if credentials.APIKey == "" {
+		return false, errors.New("api key file is empty")
+	}
+	f.Set(credentials)
+	f.apiKey, f.key = apiKey, key
+	return true, nil
+}
+
+// read returns a consistent snapshot of the API key and private key files.
+func (f *FileCredentials) read() (apiKey, key []byte, err error) {
+	for attempt := 0; attempt < maxReadAttempts; attempt++ {
+		nextAPIKey, err := os.ReadFile(f.apiKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		nextKey, err := os.ReadFile(f.privateKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if attempt > 0 && bytes.Equal(nextAPIKey, apiKey) && bytes.Equal(nextKey, key) {
+			return apiKey, key, nil
+		}
+		apiKey, key = nextAPIKey, nextKey
+	}
+	return nil, nil, errors.New("credential files keep changing while they are read")
+}
+
+// Watch reloads the files every interval (DefaultWatchInterval if 0) until ctx is done,
+// and returns ctx.Err(). onChange and onError, if not nil, are called after each reload
+// that changed the credentials or failed.
+func (f *FileCredentials) Watch(ctx context.Context, interval time.Duration, onChange func(), onError func(err error)) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		changed, err := f.Reload()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+		if changed && onChange != nil {
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+func TestInvalidCredentials(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	apiKey, _, err := srv.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	static := func(credentials *client.Credentials) client.CredentialsProvider {
+		return client.CredentialsFunc(func(ctx context.Context) (*client.Credentials, error) {
+			return credentials, nil
+		})
+	}
+	tests := []struct {
+		name        string
+		credentials client.CredentialsProvider
+	}{
+		{"nil credentials", static(nil)},
+		{"no signer", static(&client.Credentials{APIKey: apiKey})},
+		{"no API key", static(&client.Credentials{Signer: client.NewCryptoSigner(nil)})},
+		{"unset rotating credentials", client.NewRotatingCredentials(nil)},
+	}
+	for _, tt := range tests {
+		c, err := srv.Client(client.Options{Credentials: tt.credentials})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); !errors.Is(err, client.ErrInvalidCredentials) {
+			t.Errorf("%s: %v, want ErrInvalidCredentials", tt.name, err)
+		}
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d requests sent without valid credentials", n)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+
+	dir := t.TempDir()
+	apiKeyPath, privateKeyPath := filepath.Join(dir, "api-key"), filepath.Join(dir, "private-key")
+	rotate := func() string {
+		apiKey, apiKeySecret, err := srv.NewAPIKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(apiKeyPath, []byte(apiKey+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(privateKeyPath, []byte(apiKeySecret), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return apiKey
+	}
+	lastAPIKey := func() string {
+		requests := srv.Requests()
+		return requests[len(requests)-1].APIKey
+	}
+
+	first := rotate()
+	credentials, err := client.NewFileCredentials(apiKeyPath, privateKeyPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := srv.Client(client.Options{Credentials: credentials})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastAPIKey(); got != first {
+		t.Errorf("API key %q, want %q", got, first)
+	}
+
+	if changed, err := credentials.Reload(); changed || err != nil {
+		t.Errorf("Reload of unchanged files = %v, %v", changed, err)
+	}
+	second := rotate()
+	if changed, err := credentials.Reload(); !changed || err != nil {
+		t.Fatalf("Reload of rotated files = %v, %v", changed, err)
+	}
+	if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastAPIKey(); got != second {
+		t.Errorf("API key %q after rotation, want %q", got, second)
+	}
+
+	// invalid files are not loaded, the current credentials are kept
+	if err := os.WriteFile(privateKeyPath, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := credentials.Reload(); changed || !errors.Is(err, client.ErrUnsupportedKey) {
+		t.Errorf("Reload of an invalid key = %v, %v", changed, err)
+	}
+	if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); err != nil {
+		t.Errorf("request after an invalid reload: %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// URLEncode encodes the fields of a struct as the canonical query string signed by GET
+// requests: the parameters sorted by name and URL-encoded, named after the json tags of
+// the fields, following encoding/json:
+//   - fields tagged "-" and unexported fields are skipped, and so are the empty values
+//     of fields tagged omitempty (0, false, "", nil and empty slices),
+//   - nil pointers and interfaces are skipped, others are encoded as the value they point to,
+//   - slices and arrays are encoded as comma-separated values,
+//   - the fields of embedded structs are encoded as fields of the outer struct, and the
+//     fields of other nested structs as "outer.inner",
+//   - encoding.TextMarshaler and json.Marshaler values, e.g. types.Amount, are encoded
+//     as they marshal, without the quotes of JSON strings.
+//
+// Maps, channels and functions cannot be encoded.
+func URLEncode(s interface{}) (string, error) {
+	if s == nil {
+		return "", errors.New("provided value is nil")
+	}
+
+	val := reflect.ValueOf(s)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "", errors.New("provided value is nil")
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", errors.New("provided value is not a struct")
+	}
+
+	values := url.Values{}
+	if err := encodeStruct(values, "", val); err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+func encodeStruct(values url.Values, prefix string, val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if index := strings.Index(tag, ","); index != -1 {
+			name, opts = tag[:index], tag[index:]
+		}
+		fieldVal := val.Field(i)
+
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				// promote the fields of embedded structs, even unexported ones
+				if fieldVal.Kind() == reflect.Ptr {
+					if fieldVal.IsNil() {
+						continue
+					}
+					fieldVal = fieldVal.Elem()
+				}
+				if err := encodeStruct(values, prefix, fieldVal); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, ",omitempty") && isEmptyValue(fieldVal) {
+			continue
+		}
+		if err := encodeValue(values, prefix+name, fieldVal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeValue(values url.Values, name string, val reflect.Value) error {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		if isMarshaler(val.Type()) {
+			break
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() == reflect.Struct && !isMarshaler(val.Type()) && !isMarshaler(reflect.PtrTo(val.Type())) {
+		return encodeStruct(values, name+".", val)
+	}
+	if val.Kind() == reflect.Array || (val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8) {
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return nil
+		}
+		elems := make([]string, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			elem, ok, err := formatValue(val.Index(i))
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if ok {
+				elems = append(elems, elem)
+			}
+		}
+		values.Set(name, strings.Join(elems, ","))
+		return nil
+	}
+
+	value, ok, err := formatValue(val)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if ok {
+		values.Set(name, value)
+	}
+	return nil
+}
+
+// formatValue formats a scalar value. It reports false for nil values, which are not encoded.
+func formatValue(val reflect.Value) (string, bool, error) {
+	for val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", false, nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Ptr && val.IsNil() {
+		return "", false, nil
+	}
+	// the values promoted from unexported embedded structs cannot be marshaled
+	if val.CanInterface() {
+		if val.Kind() != reflect.Ptr && val.CanAddr() {
+			// marshalers implemented by the pointer type
+			if ptr := val.Addr(); isMarshaler(ptr.Type()) {
+				val = ptr
+			}
+		}
+		switch v := val.Interface().(type) {
+		case encoding.TextMarshaler:
+			text, err := v.MarshalText()
+			if err != nil {
+				return "", false, err
+			}
+			return string(text), true, nil
+		case json.Marshaler:
+			data, err := v.MarshalJSON()
+			if err != nil {
+				return "", false, err
+			}
+			data = bytes.TrimSpace(data)
+			if string(data) == "null" {
+				return "", false, nil
+			}
+			var s string
+			if json.Unmarshal(data, &s) == nil {
+				return s, true, nil
+			}
+			return string(data), true, nil
+		}
+	}
+
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", false, nil
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(val.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 64), true, nil
+	case reflect.Slice:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte is base64-encoded, as by encoding/json
+			data, err := json.Marshal(val.Interface())
+			if err != nil {
+				return "", false, err
+			}
+			return strings.Trim(string(data), `"`), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("cannot encode value of type %s", val.Type())
+}
+
+func isMarshaler(typ reflect.Type) bool {
+	return typ.Implements(textMarshalerType) || typ.Implements(jsonMarshalerType)
+}
+
+// isEmptyValue reports whether a value is empty for omitempty, as in encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// The golden signatures were computed with the key of testdata/rsa.pem by
+//
+//	printf %s "$payload" | openssl dgst -sha512 -sign rsa.pem | base64 -w0
+//
+// They check the signatures against openssl, not against Ceffu: none of the payloads is
+// an example of the Ceffu signing documentation. Such an example, with its key, should be
+// added here once available.
+func TestRequestPayloads(t *testing.T) {
+	key, err := LoadPrivateKeyFile("testdata/rsa.pem", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewRSASigner(key)
+	fee := types.MustParseAmount("0.00042")
+
+	tests := []struct {
+		name      string
+		method    string
+		request   interface{}
+		payload   string
+		signature string
+	}{
+		{
+			"CreatSubWalletRequest", http.MethodPost, &types.CreatSubWalletRequest{ParentWalletID: "473690452895207424", WalletName: "trading", AutoCollection: 1, RequestID: "req-1"},
+			`{"parentWalletId":"473690452895207424","walletName":"trading","autoCollection":1,"requestId":"req-1","timestamp":1700000000000}`,
+			"PaOjwBErcTdmbJ+kKcp0sKWiXA6VwiaL/jpMzQikFWaFYj/OrUR7hcg+8hD3XIGWCikanIMjsQw4O1pxpWy0qqIXGmtvtQC3z7vkmWxvJHjJJ8bPeKDByuVQI1ysZK6igiRLQshaXqXa2xaWLuyNUHvTYck2y14O+bznnarL259ssJo/VhICYRxs4OLjWmLYw95pEpjiL1VjsyXup1PQofd46gS7JmCEC79/d5v0vr3W07v4rlC3wkN+vGZXPQsWeDHReMeZGjH9l6RmhRgag8/4xWEcsHQQT7qNjO5QQtuVbk9/MDeug23uxqSI+OcboBPSTtZ4n2Fwx7zR2a3nvQ==",
+		},
+		{
+			"CreatSubWalletRequest omitempty", http.MethodPost, &types.CreatSubWalletRequest{ParentWalletID: "473690452895207424", RequestID: "req-2"},
+			`{"parentWalletId":"473690452895207424","requestId":"req-2","timestamp":1700000000000}`,
+			"YqHXzbN0aZktaQhTGMxkNhvdSFru5T8BmxkE3Bqy845v4Euv0vVExPcUvVQtLbhZDACuzs8LnsPbs3RqV8yAV1kEzM68ED1JqaG55OHYbkxgpW20yoW4NWPPjf49oNZyUm5XfmAR9xTriGcrKYLT1llFUfuMsAXTA0ElU0uledeLLCVtX2W43FWLP6WystWHFZiJWZokv8osIOy8WnS06i7gqPaHwsSD7J9z3b5E3YDCcCqdv7849TkuwqM5O1sFSiM1Uk40/PKhScF9A/SiwgJ/9tMgSnKuY9Qj0mFxQ9mIDhNTcn4g+v1EMO7f/kNUAwtxKMJzwTYefc7sOvcEsA==",
+		},
+		{
+			"GetDepositAddressRequest", http.MethodGet, &types.GetDepositAddressRequest{CoinSymbol: "USDT", Network: "ETH", WalletID: 473690452895207425},
+			`coinSymbol=USDT&network=ETH&timestamp=1700000000000&walletId=473690452895207425`,
+			"lGZJDOCFROsszSWWqlu/u8kRIW/YqXgCJWdvioHwNOfFGc6KR38MUVABQ+CHj+yBKo6oHbFc1KocE3TTOpUa2OqM2ZLl5CdLQhrhbWs0QQre2IQRGVMVFQZU5sFIIAaNl0Qjt9jx159icazfvBvbdX9EWqWP2uZ5IL18YZhYNJ9/0QOOa9Qamu8lKeZzWhFEOAMHDADTLCECH9AQk8DY7BgH4frSiD5H1v0NrJvjlvwblWllHAXjgJ755vtoWQFKNusCftSoO1ZpaVAG9WIoEPQcS2m5FwFVESJQmty7p5SXwB6i13R4dlFnJcawQ7QDs4UKqarLiY3WlJ5VjzMOAA==",
+		},
+		{
+			"GetDepositAddressRequest without coin", http.MethodGet, &types.GetDepositAddressRequest{Network: "BTC", WalletID: 473690452895207425},
+			`coinSymbol=&network=BTC&timestamp=1700000000000&walletId=473690452895207425`,
+			"M5O2KFPVKulYjU3fWn/NU2M4Ln9vnskRnCHRM+D3G/iCReLRK0XvF1VDlf+Fp1DRyP9AWyxIQZK8tva9qPqCqNcAcleXCAQJzC2V43Tz+M2JSfeuWLLGGL4qblVMoLEgA8/mpPBZKJONKOXJgi37KUa/O94pKqWCwEPClMtZ17++Sy6jmFwa5FrxJ8truqGXsQxxz6JT52icG1UOS837jvSVdh7f165nNHaILFlnGUcJc2eB2lzOs62H2rU4MYYP2NnDCumWiMLxY5HscxssAmG3ruDQL8zf2Wgr78qr9r8Xdtoyki+d97VMrI18KTHtsksuBbQZtm9x1mL2+4DfRQ==",
+		},
+		{
+			"GetDepositHistoryRequest", http.MethodGet, &types.GetDepositHistoryRequest{WalletID: 473690452895207425, CoinSymbol: "USDT", Network: "TRX", StartTime: 1690000000000, EndTime: 1700000000000, PageLimit: 500, PageNo: 1},
+			`coinSymbol=USDT&endTime=1700000000000&network=TRX&pageLimit=500&pageNo=1&startTime=1690000000000&timestamp=1700000000000&walletId=473690452895207425`,
+			"gQgVZIgDBrpszwG/7K1fIn7QJatNn7omYfVk9If/k4SRkOzmcPnSqN8EM6njai5bwI3e7IdZckFjmc9XljSG6zp0sduZZaK3NfCg985kZkJTC7avFL3Ar0NCQZ5Khx8qCkJ5OgQli31oktNf9V6SdJ8U143z95lKTYZtAuuJ6Ft/U82vpjSNPWzWxINQ3d3OmN3m1eFZR1UqiRg4OmwU2waVrAKjUAl5a0uiRXxGRZAHUehAwyPcX17jQykG/OaThT460oSC4dpodfvDjwf7Vrc1v1p2JeIu7zyWFcfmT6nhcf9DKnGTezyc2LnznMnR1OLFEzxZaKU8EE3AbXIDyw==",
+		},
+		{
+			"GetDepositHistoryRequest omitempty", http.MethodGet, &types.GetDepositHistoryRequest{WalletID: 473690452895207425, StartTime: 1690000000000, EndTime: 1700000000000, PageLimit: 500, PageNo: 1},
+			`endTime=1700000000000&pageLimit=500&pageNo=1&startTime=1690000000000&timestamp=1700000000000&walletId=473690452895207425`,
+			"fN57A0+RELYXm105GZ6G2TDHjxxOQ3DENeEo3AzNJcfNufiLUFdBUw716FoINuhu0EXldMDWLIvOLWOHdyPa5onu70qF6w9AvuDYECexK51f28epp6n1J0oV+k05U2bvQeV7EzHbILZWeVzE2McWh77aKmEVGUu+OWZNhOg/PTN7xYvfSqkBFZ0ZBl3a08iOD9l67S9T9Y28Ykkl8Vc1hEnoxyFz9JW/fOsb/9NNKVz4ohZfRL8izDUZvUZAibj6aD2tYBoeP/XgU6gGukTINSn1jTyh/VNdo0rRfdSG9d30wpXMcX/e5/QxkUldVw/j6fiCUoVD8xejsHt2r0HyCQ==",
+		},
+		{
+			"TransferRequest", http.MethodPost, &types.TransferRequest{CoinSymbol: "ETH", Amount: types.NumberAmount{Amount: types.MustParseAmount("1.000000000000000001")}, FromWalletID: 473690452895207424, ToWalletID: 473690452895207425, RequestID: "req-3"},
+			`{"coinSymbol":"ETH","amount":1.000000000000000001,"fromWalletId":473690452895207424,"toWalletId":473690452895207425,"requestId":"req-3","timestamp":1700000000000}`,
+			"nmiRaM/Ms+qQr2uVB1j586ePKFSPBbZpmvW9p90dOyQMsX43FZa55TnoyMh17Ln7wVpw6+JgfPDIYsK0A85fz410ipcJs/bGaNt9mMrs45MR9ihx+urDrjANGMDT2krQ4lhmi91DBgx4vxtZgdwJkqL+wNXwfecotT60g7Kk5JKPfSj+heFIgV4m7nk15Kh6nsfdt4wfEXpPJasQMrqsZ04t63cOacCk/ldAyWthBpbNm2j8SZoPj5u1+5QP1scuTqljzbexuJWjq1QcKamq+NPxS53Lhe1BTT4OJgrf+V8Apx4DugdooivsHKk57WQl5uBu62hRGi5teestNAzJCg==",
+		},
+		{
+			"WithdrawalRequest", http.MethodPost, &types.WithdrawalRequest{Amount: types.MustParseAmount("12.5"), CoinSymbol: "XRP", Memo: "123456", Network: "XRP", WalletID: 473690452895207424, WithdrawalAddress: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", CustomizeFeeAmount: &fee, RequestID: "req-4"},
+			`{"amount":"12.5","coinSymbol":"XRP","memo":"123456","network":"XRP","walletId":473690452895207424,"withdrawalAddress":"rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh","toWalletIdStr":"","customizeFeeAmount":"0.00042","requestId":"req-4","timestamp":1700000000000}`,
+			"HJ3tKa/xYJxjhBzeCwsyH3Ij14lSpa2VXE0yM2ImKnQYogamqoRbOzEmuaUjDOa6q9ip+tlgmP9ArUYRUHIG9rNeeegLjyjKk0Dk95M805LraTCdp32tz+ALn/+6BCwPi2u4DOWXK9Gg++pn3KlDziD2kc+2y1dToupoFNPl/5g92tKHavs+J0Ux4Cnm8Os5BbtAzdMM8KJs5qBxsRD4l26KHwP3oIangAIQlYaq0ig2tgzq+hfqcNQv386bCpvKlOo8CLWwI4Y39xZGV3m/rjTX2tuDx0ToQWy99826NQ9HGlsY/VQLt2Y+JJeCsnJMXdbth0pdYQzOY8ZM9h5QxA==",
+		},
+		{
+			"WithdrawalRequest to a wallet", http.MethodPost, &types.WithdrawalRequest{Amount: types.MustParseAmount("0.1"), CoinSymbol: "BTC", Network: "BTC", WalletID: 473690452895207424, ToWalletIDStr: "473690452895207426", RequestID: "req-5"},
+			`{"amount":"0.1","coinSymbol":"BTC","network":"BTC","walletId":473690452895207424,"withdrawalAddress":"","toWalletIdStr":"473690452895207426","requestId":"req-5","timestamp":1700000000000}`,
+			"KwYHATO3zGLhJ5mOb6+FcwLWb/AHC8RA1Z51zppFtlIuBsdXyK6+GH68tdUMN4HQjVtVSd9Pc84gEXCTBbdrRepn0ClyCURWvJwZ7hAolbgPNNyVH/hAySUFEIC2nMY+rGVoBEJ2x1zkyvDiMN6O9Of3JrENTsNw2sidWS7ORUrzOfcs0AbIKJPpMY7TqGx1ABVjfh0chv1+oqOraFpiYU98eATSnODw0PA/yC6yUqBwkDOrbMihKUr4PHZAS5RwqV2SoAB5dYqCf0QI4Y2aRGf5aX8wfZiuZm3M6r+Es1YV8xhp/PR1m5/PRGY+6HTM8SsR5XrmyrpA6J+co7sxNQ==",
+		},
+		{
+			"WithdrawalDetailRequest", http.MethodGet, &types.WithdrawalDetailRequest{OrderViewID: "1000002"},
+			`orderViewId=1000002&timestamp=1700000000000`,
+			"h5PpcOr7sK1nkoI4eEkVzJ9E6QoFKi3X1c4tf0DOGo/Bwo739MIX5OEHJoYRsed2V9ML0UcSbODzvIM6pugYO+jIR5AAivAjtq8c+sDH5Dd3vEC0SkDhnjRbrbx7BV0jMewRRONghySTLUulD6nCovX1TlSnXmmGmuJHmb2AYJ1+o0Rr+NnBzKEF2rXfFxsvDE1PRs1/nLU0dg4tK2ZUOWmv3hRuDQ3FHqCIA7LGNc0Hffj5lJi9K1EZ8FXywecDJwAiBAY/Bas27hkWqVk5BqlBcWjrvDAy6HJ8sIpzc4caFBqKxSLEFlx8tCdRpSd+CCtIWfY6OQgw7Xs9o93hhg==",
+		},
+		{
+			"TransferWithExchangeRequest", http.MethodPost, &types.TransferWithExchangeRequest{Amount: types.MustParseAmount("250"), CoinSymbol: "USDT", Direction: types.ExchangeTransferDirectionCustodyToExchange, ExchangeCode: 10, ExchangeUserID: "35990001", ParentWalletID: 473690452895207424, RequestID: "req-6"},
+			`{"amount":"250","coinSymbol":"USDT","direction":10,"exchangeCode":10,"exchangeUserId":"35990001","parentWalletId":473690452895207424,"requestId":"req-6","timestamp":1700000000000}`,
+			"oNBXyXxHfsf51K+wAE+PR9ntChsfqkhKivwiwLbXpYN2P4RXxkKCKqDeQSVkPOBFdJuZlcVy9mWiNsAtgvCsQ/QI7i5NJYpNl44v1o7rvn4nN2aCzMXMBGZvqTzA/e7RQC3AA8VPyvbVHWoIrofi/wW84z3z9Hd8CK1S4Sm5fUMeJXeaZqe+9tWI7Kj8u2dTgStJMBIJp/TgnXYO402May6uKnSoNYIpEiu4FlRF4IxrGGDQXPwUCO2cLBRl/6h/JhOvSqfNJ5VotLCyzWtRkrNUFGq0mgm0zZLsAoUTv8Nsx/tIF3ZVyyzYIZKbfP8FSeyLugjZnkIJjBiyJQuPFA==",
+		},
+		{
+			"TransferDetailWithExchangeRequest", http.MethodPost, &types.TransferDetailWithExchangeRequest{OrderViewID: "1000003", WalletID: 473690452895207424},
+			`{"orderViewId":"1000003","timestamp":1700000000000,"walletId":473690452895207424}`,
+			"ItXy97mfAOIujy85EdGc2+1lXEl2ddxiGGUTbw2GlJVTMmWcQEF/NdA4Cns6j9/UOMr/lPQ9ES4Ih0xaxEaTFC6wem6IkGDQXoYq553ZAfhJjK9bPs/7O//JuwmB+iaZZ39mtla5BI6/rg36Qyumi1kWyi4dO8URMMNa05w160V0zaP/orUc3oecBEZYKoQxyzxDiRrxcJFCk77Dz4C/HB3gTZhtZMNCYbhQYIXBCiImPxdnQC035oRWx469WdH325KYZd1Ya5iRW6ZJmrHqPWiQw3U7KJz30cZhF0RVIBpWj0Z7CfJ2BeOMY/4avuUKIuyWkN4Zv1YZm51Xu7kFHg==",
+		},
+	}
+	for _, tt := range tests {
+		setTimestamp(tt.request, 1700000000000)
+		payload, err := encodePayload(tt.method, tt.request)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if payload != tt.payload {
+			t.Errorf("%s: payload\n%s\nwant\n%s", tt.name, payload, tt.payload)
+			continue
+		}
+		signature, err := signer.Sign(context.Background(), []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signature != tt.signature {
+			t.Errorf("%s: signature %s, want %s", tt.name, signature, tt.signature)
+		}
+	}
+}
+
+type upperText string
+
+func (u upperText) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(u))), nil
+}
+
+type jsonNumber struct{ n int }
+
+func (j *jsonNumber) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.n * 10)
+}
+
+type Page struct {
+	PageNo    int64 `json:"pageNo"`
+	PageLimit int64 `json:"pageLimit,omitempty"`
+}
+
+type filter struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to,omitempty"`
+}
+
+func TestURLEncode(t *testing.T) {
+	id, symbol, amount := int64(42), "BTC", types.MustParseAmount("0.1")
+	var nilAmount *types.Amount
+
+	tests := []struct {
+		name    string
+		request interface{}
+		want    string
+	}{
+		{"omitempty", &struct {
+			S string  `json:"s,omitempty"`
+			I int64   `json:"i,omitempty"`
+			F float64 `json:"f,omitempty"`
+			B bool    `json:"b,omitempty"`
+			P *int64  `json:"p,omitempty"`
+			L []int64 `json:"l,omitempty"`
+			Z int64   `json:"z"`
+			E string  `json:"e"`
+		}{}, "e=&z=0"},
+		{"pointers", struct {
+			ID     *int64         `json:"id"`
+			Symbol *string        `json:"symbol,omitempty"`
+			Nil    *string        `json:"nil"`
+			Amount *types.Amount  `json:"amount"`
+			None   *types.Amount  `json:"none"`
+			Iface  interface{}    `json:"iface"`
+			Ptrs   **types.Amount `json:"ptrs"`
+		}{&id, &symbol, nil, &amount, nilAmount, &id, &nilAmount}, "amount=0.1&id=42&iface=42&symbol=BTC"},
+		{"slices", struct {
+			Symbols []string       `json:"symbols"`
+			IDs     [2]int64       `json:"ids"`
+			Amounts []types.Amount `json:"amounts"`
+			Nil     []string       `json:"nil"`
+			Empty   []string       `json:"empty"`
+			Bytes   []byte         `json:"bytes"`
+		}{[]string{"BTC", "ETH"}, [2]int64{1, 2}, []types.Amount{amount, types.MustParseAmount("2")}, nil, []string{}, []byte("ceffu")}, "amounts=0.1%2C2&bytes=Y2VmZnU%3D&empty=&ids=1%2C2&symbols=BTC%2CETH"},
+		{"nested structs", struct {
+			Page
+			*filter
+			Window filter  `json:"window"`
+			Range  *filter `json:"range"`
+			Tagged Page    `json:"tagged,omitempty"`
+		}{Page{PageNo: 1}, &filter{From: 5}, filter{From: 1, To: 2}, nil, Page{}}, "from=5&pageNo=1&tagged.pageNo=0&window.from=1&window.to=2"},
+		{"marshalers", &struct {
+			Text   upperText          `json:"text"`
+			JSON   jsonNumber         `json:"json"`
+			Amount types.Amount       `json:"amount"`
+			Number types.NumberAmount `json:"number"`
+		}{"eth", jsonNumber{3}, types.MustParseAmount("1e-3"), types.NumberAmount{Amount: amount}}, "amount=0.001&json=30&number=0.1&text=ETH"},
+		{"skipped fields", struct {
+			Skipped  string `json:"-"`
+			private  string
+			Untagged string
+			Escaped  string `json:"q"`
+		}{"a", "b", "c", "a b&c=d"}, "Untagged=c&q=a+b%26c%3Dd"},
+	}
+	for _, tt := range tests {
+		got, err := URLEncode(tt.request)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	var nilRequest *types.WithdrawalDetailRequest
+	for name, request := range map[string]interface{}{
+		"nil":         nil,
+		"nil pointer": nilRequest,
+		"not struct":  "coinSymbol=BTC",
+		"map":         struct{ M map[string]string }{map[string]string{"a": "b"}},
+		"func":        struct{ F func() }{func() {}},
+	} {
+		if got, err := URLEncode(request); err == nil {
+			t.Errorf("%s: %q, want an error", name, got)
+		}
+	}
+}
@@ -1,15 +1,42 @@
 package client
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors for the categories of failures returned by Ceffu. A *RequestError
+// matches them with errors.Is, based on its HTTP status code:
+//
+//	if errors.Is(err, client.ErrRateLimited) { ... }
+//
+// Ceffu does not publish a table of its API response codes besides SuccessCode, so no
+// API code is mapped to a category by default. Codes known from the Ceffu console or
+// support can be mapped per client with Options.ErrorCodes, to these sentinels or to
+// errors of the caller. ErrAddressNotWhitelisted is returned by ValidateWithdrawal.
+var (
+	ErrUnauthorized          = errors.New("ceffu: unauthorized")
+	ErrRateLimited           = errors.New("ceffu: rate limited")
+	ErrAddressNotWhitelisted = errors.New("ceffu: address not whitelisted")
+	ErrServer                = errors.New("ceffu: server error")
+)
 
 type RequestError struct {
-	Path    string
-	Method  string
-	Param   string
-	Code    string
-	Message string
-	Body    []byte
-	Err     error
+	Path       string
+	Method     string
+	Param      string
+	StatusCode int
+	Code       string
+	Message    string
+	Body       []byte
+	Err        error
+	// Redaction is how the sensitive values of Param and Body are hidden by Error,
+	// the Options.Redaction of the client.
+	Redaction Redaction
+	// ErrorCodes maps the API codes to the errors matched by Is, the Options.ErrorCodes
+	// of the client.
+	ErrorCodes map[string]error
 }
 
 func NewRequestError(path string, opts ...ErrorOption) *RequestError {
@@ -22,6 +49,15 @@ func NewRequestError(path string, opts ...ErrorOption) *RequestError {
 	return &ext
 }
 
+// newRequestError returns a RequestError hiding the sensitive values with the redaction
+// of the client, and categorized with its error codes.
+func (c *client) newRequestError(path string, opts ...ErrorOption) *RequestError {
+	return NewRequestError(path, append(opts, WithRedaction(c.redaction), WithErrorCodes(c.errorCodes))...)
+}
+
+// Error describes the error. The addresses, API keys and signatures in Param and Body
+// are hidden according to Redaction, and both are truncated, so that the message is
+// safe to log.
 func (e *RequestError) Error() string {
 	msg := fmt.Sprintf("Error while making request to %s,", e.Path)
 
@@ -29,7 +65,7 @@ func (e *RequestError) Error() string {
 		msg += fmt.Sprintf(" method: %s, ", e.Method)
 	}
 	if e.Param != "" {
-		msg += fmt.Sprintf(" param: %s, ", e.Param)
+		msg += fmt.Sprintf(" param: %s, ", truncate(RedactPayload(e.Param, e.Redaction)))
 	}
 	if e.Code != "" {
 		msg += fmt.Sprintf("code: %s, ", e.Code)
@@ -38,10 +74,40 @@ func (e *RequestError) Error() string {
 		msg += fmt.Sprintf("message: %s, ", e.Message)
 	}
 	if len(e.Body) > 0 {
-		msg += fmt.Sprintf("body: %s, ", string(e.Body))
+		msg += fmt.Sprintf("body: %s, ", truncate(RedactPayload(string(e.Body), e.Redaction)))
 	}
 	if e.Err != nil {
 		msg += fmt.Sprintf("error: %s", e.Err.Error())
 	}
 	return msg
 }
+
+// Unwrap returns the underlying error, e.g. a transport error or context.DeadlineExceeded.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error belongs to the category of target, one of the Err* sentinels
+// or an error of ErrorCodes.
+func (e *RequestError) Is(target error) bool {
+	category := e.Category()
+	return category != nil && category == target
+}
+
+// Category returns the error of ErrorCodes matching the API code, or else the sentinel
+// error matching the HTTP status code of the error, or nil if it doesn't belong to a
+// known category.
+func (e *RequestError) Category() error {
+	if err := e.ErrorCodes[e.Code]; err != nil && e.Code != "" {
+		return err
+	}
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRequestErrorCategory(t *testing.T) {
+	errFrozen := errors.New("wallet frozen")
+	codes := map[string]error{
+		"429001": ErrRateLimited,
+		"300001": errFrozen,
+	}
+	tests := []struct {
+		name  string
+		opts  []ErrorOption
+		want  error
+		notIs []error
+	}{
+		{"429", []ErrorOption{WithStatusCode(http.StatusTooManyRequests)}, ErrRateLimited, []error{ErrServer}},
+		{"401", []ErrorOption{WithStatusCode(http.StatusUnauthorized)}, ErrUnauthorized, nil},
+		{"403", []ErrorOption{WithStatusCode(http.StatusForbidden)}, ErrUnauthorized, nil},
+		{"500", []ErrorOption{WithStatusCode(http.StatusInternalServerError)}, ErrServer, nil},
+		{"503", []ErrorOption{WithStatusCode(http.StatusServiceUnavailable)}, ErrServer, []error{ErrRateLimited}},
+		{"400", []ErrorOption{WithStatusCode(http.StatusBadRequest)}, nil, []error{ErrServer, ErrUnauthorized, ErrRateLimited}},
+		{"mapped code", []ErrorOption{WithCode("429001"), WithErrorCodes(codes)}, ErrRateLimited, nil},
+		{"caller error", []ErrorOption{WithCode("300001"), WithErrorCodes(codes)}, errFrozen, []error{ErrServer}},
+		{"code before status", []ErrorOption{WithCode("300001"), WithStatusCode(http.StatusInternalServerError), WithErrorCodes(codes)}, errFrozen, []error{ErrServer}},
+		{"unknown code", []ErrorOption{WithCode("999999"), WithStatusCode(http.StatusBadGateway), WithErrorCodes(codes)}, ErrServer, []error{errFrozen}},
+		{"not mapped", []ErrorOption{WithCode("300001")}, nil, []error{errFrozen}},
+		{"no code", []ErrorOption{WithErrorCodes(map[string]error{"": errFrozen})}, nil, []error{errFrozen}},
+	}
+	for _, tt := range tests {
+		err := NewRequestError(PathWithdrawal, tt.opts...)
+		if got := err.Category(); got != tt.want {
+			t.Errorf("%s: Category() = %v, want %v", tt.name, got, tt.want)
+		}
+		// the category is matched through wrapping errors
+		wrapped := fmt.Errorf("withdraw: %w", err)
+		if tt.want != nil && !errors.Is(wrapped, tt.want) {
+			t.Errorf("%s: errors.Is(%v) = false", tt.name, tt.want)
+		}
+		for _, target := range tt.notIs {
+			if errors.Is(wrapped, target) {
+				t.Errorf("%s: errors.Is(%v) = true", tt.name, target)
+			}
+		}
+		var requestErr *RequestError
+		if !errors.As(wrapped, &requestErr) || requestErr != err {
+			t.Errorf("%s: errors.As did not find the *RequestError", tt.name)
+		}
+	}
+}
+
+func TestRequestErrorUnwrap(t *testing.T) {
+	cause := &url.Error{Op: "Get", URL: "https://example.com", Err: context.DeadlineExceeded}
+	err := NewRequestError(PathWithdrawal, WithError(cause))
+	if err.Unwrap() != cause {
+		t.Errorf("Unwrap() = %v, want %v", err.Unwrap(), cause)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("errors.Is(context.DeadlineExceeded) = false")
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || urlErr != cause {
+		t.Error("errors.As did not find the *url.Error")
+	}
+	if err.Category() != nil || errors.Is(err, ErrServer) {
+		t.Errorf("transport error categorized as %v", err.Category())
+	}
+	if NewRequestError(PathWithdrawal).Unwrap() != nil {
+		t.Error("Unwrap() of an API error is not nil")
+	}
+}
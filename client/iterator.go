@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// Done is returned by the Next method of iterators when there are no more items.
+var Done = errors.New("no more items in iterator")
+
+// MaxHistoryWindow is the longest time interval accepted by a single history query.
+const MaxHistoryWindow = 30 * 24 * time.Hour
+
+// DefaultHistoryPageLimit is the page limit used by iterators when none is given.
+const DefaultHistoryPageLimit = 100
+
+type transactionPageFunc func(ctx context.Context, startTime, endTime, pageNo, pageLimit int64) (*types.TransactionPage, error)
+
+// transactionIterator splits [startTime, endTime] into windows no longer than
+// MaxHistoryWindow and walks every page of each window.
+type transactionIterator struct {
+	fetch     transactionPageFunc
+	endTime   int64
+	pageLimit int64
+
+	windowStart int64
+	windowEnd   int64
+	pageNo      int64
+	items       []*types.Transaction
+	done        bool
+}
+
+func newTransactionIterator(fetch transactionPageFunc, startTime, endTime, pageLimit int64) transactionIterator {
+	if pageLimit <= 0 {
+		pageLimit = DefaultHistoryPageLimit
+	}
+	it := transactionIterator{
+		fetch:       fetch,
+		endTime:     endTime,
+		pageLimit:   pageLimit,
+		windowStart: startTime,
+		pageNo:      1,
+		done:        startTime > endTime,
+	}
+	it.windowEnd = it.nextWindowEnd()
+	return it
+}
+
+// Next returns the next transaction. It returns Done when all transactions in
+// the time range have been returned.
+func (it *transactionIterator) Next(ctx context.Context) (*types.Transaction, error) {
+	for len(it.items) == 0 {
+		if it.done {
+			return nil, Done
+		}
+		if err := it.fetchPage(ctx); err != nil {
+			return nil, err
+		}
+	}
+	tx := it.items[0]
+	it.items = it.items[1:]
+	return tx, nil
+}
+
+func (it *transactionIterator) fetchPage(ctx context.Context) error {
+	page, err := it.fetch(ctx, it.windowStart, it.windowEnd, it.pageNo, it.pageLimit)
+	if err != nil {
+		return err
+	}
+	it.items = page.Data
+
+	if len(page.Data) == 0 || it.pageNo >= int64(page.TotalPage) {
+		it.nextWindow()
+		return nil
+	}
+	it.pageNo++
+	return nil
+}
+
+func (it *transactionIterator) nextWindow() {
+	if it.windowEnd >= it.endTime {
+		it.done = true
+		return
+	}
+	it.windowStart = it.windowEnd + 1
+	it.windowEnd = it.nextWindowEnd()
+	it.pageNo = 1
+}
+
+func (it *transactionIterator) nextWindowEnd() int64 {
+	end := it.windowStart + MaxHistoryWindow.Milliseconds() - 1
+	if end > it.endTime {
+		end = it.endTime
+	}
+	return end
+}
+
+// DepositHistoryIterator walks the deposit history of a wallet over an arbitrary time range.
+type DepositHistoryIterator struct {
+	transactionIterator
+}
+
+// NewDepositHistoryIterator returns an iterator over the deposit history of the requested
+// wallet between startTime and endTime (timestamps in milliseconds, both inclusive).
+// The time range is split into windows of at most MaxHistoryWindow and every page
+// of each window is fetched with GetDepositHistoryPage.
+// If pageLimit is not positive, DefaultHistoryPageLimit is used.
+func NewDepositHistoryIterator(c SubWallet, walletID int64, symbol, network string, startTime, endTime, pageLimit int64) *DepositHistoryIterator {
+	fetch := func(ctx context.Context, startTime, endTime, pageNo, pageLimit int64) (*types.TransactionPage, error) {
+		return c.GetDepositHistoryPage(ctx, walletID, symbol, network, startTime, endTime, pageNo, pageLimit)
+	}
+	return &DepositHistoryIterator{
+		transactionIterator: newTransactionIterator(fetch, startTime, endTime, pageLimit),
+	}
+}
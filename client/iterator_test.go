@@ -0,0 +1,127 @@
+package client_test
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+func TestDepositHistoryIteratorWindows(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	c, err := srv.Client(client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	window := client.MaxHistoryWindow.Milliseconds()
+	endTime := time.Now().UnixMilli()
+	startTime := endTime - 75*24*time.Hour.Milliseconds()
+	// the deposits around the boundaries of the 3 windows, and enough in the second
+	// window for several pages
+	at := []int64{
+		startTime,
+		startTime + window - 1,
+		startTime + window,
+		startTime + window + 1,
+		startTime + 2*window - 1,
+		startTime + 2*window,
+		endTime,
+	}
+	for i := int64(0); i < 5; i++ {
+		at = append(at, startTime+window+(i+2)*time.Hour.Milliseconds())
+	}
+	deposits := make(map[string]bool)
+	for _, ms := range at {
+		ms := ms
+		srv.Now = func() time.Time { return time.UnixMilli(ms) }
+		id, err := srv.Deposit(walletID, "ETH", "ETH", types.MustParseAmount("1"), "0xsender")
+		if err != nil {
+			t.Fatal(err)
+		}
+		deposits[id] = false
+	}
+	// outside of the range
+	for _, ms := range []int64{startTime - 1, endTime + 1} {
+		ms := ms
+		srv.Now = func() time.Time { return time.UnixMilli(ms) }
+		if _, err := srv.Deposit(walletID, "ETH", "ETH", types.MustParseAmount("1"), "0xsender"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.Now = time.Now
+
+	const pageLimit = 3
+	it := client.NewDepositHistoryIterator(c, walletID, "", "", startTime, endTime, pageLimit)
+	for {
+		tx, err := it.Next(context.Background())
+		if err == client.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen, ok := deposits[tx.OrderViewID]
+		if !ok || seen {
+			t.Errorf("deposit %s returned again or out of the range", tx.OrderViewID)
+		}
+		deposits[tx.OrderViewID] = true
+	}
+	for id, seen := range deposits {
+		if !seen {
+			t.Errorf("deposit %s not returned", id)
+		}
+	}
+
+	// the windows follow each other without gap nor overlap, and every page of a window
+	// is queried once, up to its TotalPage
+	type query struct{ start, end, pageNo int64 }
+	var queries []query
+	for _, r := range srv.Requests() {
+		if r.Path != client.PathDepositHistory {
+			continue
+		}
+		values, err := url.ParseQuery(r.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var q query
+		for key, v := range map[string]*int64{"startTime": &q.start, "endTime": &q.end, "pageNo": &q.pageNo} {
+			if *v, err = strconv.ParseInt(values.Get(key), 10, 64); err != nil {
+				t.Fatalf("%s: %v", key, err)
+			}
+		}
+		queries = append(queries, q)
+	}
+	windows := []struct {
+		start, end int64
+		pages      int64
+	}{
+		{startTime, startTime + window - 1, 1},            // 2 deposits
+		{startTime + window, startTime + 2*window - 1, 3}, // 8 deposits
+		{startTime + 2*window, endTime, 1},                // 2 deposits
+	}
+	i := 0
+	for _, w := range windows {
+		if w.end-w.start+1 > window {
+			t.Fatalf("window [%d, %d] longer than MaxHistoryWindow", w.start, w.end)
+		}
+		for pageNo := int64(1); pageNo <= w.pages; pageNo++ {
+			want := query{w.start, w.end, pageNo}
+			if i >= len(queries) || queries[i] != want {
+				t.Fatalf("queries = %v, want %v at %d", queries, want, i)
+			}
+			i++
+		}
+	}
+	if i != len(queries) {
+		t.Errorf("queries = %v, want %d queries", queries, i)
+	}
+}
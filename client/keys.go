@@ -0,0 +1,192 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	ErrUnsupportedKey      = errors.New("ceffu: unsupported key format")
+	ErrPassphraseRequired  = errors.New("ceffu: key is encrypted, passphrase required")
+	ErrIncorrectPassphrase = errors.New("ceffu: incorrect passphrase")
+)
+
+// ParsePrivateKey parses an RSA private key, detecting its format:
+//   - PEM, or DER either raw or base64-encoded,
+//   - PKCS #1 or PKCS #8,
+//   - PEM encrypted with a passphrase as PKCS #8 (PBES2), e.g. by openssl pkcs8 -topk8.
+//
+// Only RSA keys are supported, keys of other algorithms are rejected. Legacy
+// encrypted PEM (Proc-Type header) is insecure and rejected too, convert it with
+// openssl pkcs8 -topk8. passphrase is only used for encrypted keys.
+func ParsePrivateKey(data []byte, passphrase []byte) (*rsa.PrivateKey, error) {
+	data = bytes.TrimSpace(data)
+	if !isPEM(data) {
+		der, err := decodeDER(data)
+		if err != nil {
+			return nil, err
+		}
+		return parsePrivateKeyDER(der)
+	}
+
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("%w: no private key in PEM data", ErrUnsupportedKey)
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			// e.g. CERTIFICATE
+			continue
+		}
+		if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+			return nil, fmt.Errorf("%w: legacy PEM encryption, convert the key with openssl pkcs8 -topk8", ErrUnsupportedKey)
+		}
+
+		der := block.Bytes
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			if len(passphrase) == 0 {
+				return nil, ErrPassphraseRequired
+			}
+			decrypted, err := decryptPKCS8(der, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			der = decrypted
+		}
+		return parsePrivateKeyDER(der)
+	}
+}
+
+// ParsePublicKey parses an RSA public key in PEM or DER, either raw or base64-encoded,
+// as PKIX, PKCS #1 or the public key of an X.509 certificate.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	data = bytes.TrimSpace(data)
+	if !isPEM(data) {
+		der, err := decodeDER(data)
+		if err != nil {
+			return nil, err
+		}
+		return parsePublicKeyDER(der)
+	}
+
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("%w: no public key in PEM data", ErrUnsupportedKey)
+		}
+		if strings.HasSuffix(block.Type, "PUBLIC KEY") || block.Type == "CERTIFICATE" {
+			return parsePublicKeyDER(block.Bytes)
+		}
+	}
+}
+
+// LoadPrivateKeyFile parses the private key stored in a file, see ParsePrivateKey.
+func LoadPrivateKeyFile(path string, passphrase []byte) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data, passphrase)
+}
+
+// LoadPrivateKeyEnv parses the private key stored in an environment variable, see
+// ParsePrivateKey. PEM keys may have their line breaks escaped as \n.
+func LoadPrivateKeyEnv(name string, passphrase []byte) (*rsa.PrivateKey, error) {
+	data, err := lookupEnv(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data, passphrase)
+}
+
+// LoadPublicKeyFile parses the public key stored in a file, see ParsePublicKey.
+func LoadPublicKeyFile(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+// LoadPublicKeyEnv parses the public key stored in an environment variable, see
+// ParsePublicKey. PEM keys may have their line breaks escaped as \n.
+func LoadPublicKeyEnv(name string) (*rsa.PublicKey, error) {
+	data, err := lookupEnv(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+func parsePrivateKeyDER(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a PKCS #1 or PKCS #8 private key", ErrUnsupportedKey)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an RSA private key", ErrUnsupportedKey, key)
+	}
+	return rsaKey, nil
+}
+
+func parsePublicKeyDER(der []byte) (*rsa.PublicKey, error) {
+	var key interface{}
+	if pkix, err := x509.ParsePKIXPublicKey(der); err == nil {
+		key = pkix
+	} else if pkcs1, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		key = pkcs1
+	} else if cert, err := x509.ParseCertificate(der); err == nil {
+		key = cert.PublicKey
+	} else {
+		return nil, fmt.Errorf("%w: not a PKIX or PKCS #1 public key", ErrUnsupportedKey)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an RSA public key", ErrUnsupportedKey, key)
+	}
+	return rsaKey, nil
+}
+
+func isPEM(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("-----BEGIN "))
+}
+
+// decodeDER returns raw DER unchanged, and decodes base64 DER ignoring line breaks.
+// A DER key is an ASN.1 SEQUENCE with a long-form length: 0x30 then a byte >= 0x80,
+// which is never the start of base64 text.
+func decodeDER(data []byte) ([]byte, error) {
+	if len(data) > 1 && data[0] == 0x30 && data[1] >= 0x80 {
+		return data, nil
+	}
+	s := strings.Join(strings.Fields(string(data)), "")
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(s); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: neither PEM, DER nor base64-encoded DER", ErrUnsupportedKey)
+}
+
+func lookupEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("ceffu: environment variable %s is not set", name)
+	}
+	if strings.HasPrefix(value, "-----BEGIN ") {
+		value = strings.ReplaceAll(value, `\n`, "\n")
+	}
+	return []byte(value), nil
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The keys of testdata were generated with OpenSSL 3.0, the encrypted ones with the
+// passphrase "ceffu":
+//
+//	openssl genrsa -traditional -out rsa.pem 2048
+//	openssl pkcs8 -topk8 -nocrypt -in rsa.pem -out pkcs8.pem
+//	openssl rsa -in rsa.pem -pubout -out public.pem
+//	openssl pkcs8 -topk8 -in rsa.pem -v2 aes-256-cbc -v2prf hmacWithSHA256 -out aes256_sha256.pem
+//	openssl pkcs8 -topk8 -in rsa.pem -v2 aes-128-cbc -v2prf hmacWithSHA1 -out aes128_sha1.pem
+//	openssl pkcs8 -topk8 -in rsa.pem -v2 aes-192-cbc -v2prf hmacWithSHA512 -out aes192_sha512.pem
+//	openssl pkcs8 -topk8 -in rsa.pem -v2 des3 -out des3_sha256.pem
+//	openssl rsa -in rsa.pem -traditional -aes256 -out legacy.pem
+//	openssl ecparam -name prime256v1 -genkey -noout | openssl pkcs8 -topk8 -nocrypt -out ec.pem
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	want, err := ParsePrivateKey(readTestdata(t, "rsa.pem"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := x509.MarshalPKCS1PrivateKey(want)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := base64.StdEncoding.EncodeToString(pkcs8)
+	wrapped = wrapped[:64] + "\n" + wrapped[64:]
+
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase string
+	}{
+		{"PKCS #8 PEM", readTestdata(t, "pkcs8.pem"), ""},
+		{"PKCS #1 DER", pkcs1, ""},
+		{"PKCS #8 DER", pkcs8, ""},
+		{"PKCS #1 base64", []byte(base64.StdEncoding.EncodeToString(pkcs1)), ""},
+		{"PKCS #8 base64 with line breaks", []byte(wrapped), ""},
+		{"PKCS #8 raw URL base64", []byte(base64.RawURLEncoding.EncodeToString(pkcs8)), ""},
+		{"AES-256-CBC, HMAC-SHA256", readTestdata(t, "aes256_sha256.pem"), "ceffu"},
+		{"AES-128-CBC, HMAC-SHA1", readTestdata(t, "aes128_sha1.pem"), "ceffu"},
+		{"AES-192-CBC, HMAC-SHA512", readTestdata(t, "aes192_sha512.pem"), "ceffu"},
+		{"DES-EDE3-CBC, HMAC-SHA256", readTestdata(t, "des3_sha256.pem"), "ceffu"},
+		{"passphrase of an unencrypted key", readTestdata(t, "rsa.pem"), "ceffu"},
+	}
+	for _, tt := range tests {
+		key, err := ParsePrivateKey(tt.data, []byte(tt.passphrase))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !key.Equal(want) {
+			t.Errorf("%s: parsed another key", tt.name)
+		}
+	}
+}
+
+func TestParsePrivateKeyErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0x30, 0x82, 0x00, 0x00}})
+
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase string
+		want       error
+	}{
+		{"encrypted without passphrase", readTestdata(t, "aes256_sha256.pem"), "", ErrPassphraseRequired},
+		{"incorrect passphrase", readTestdata(t, "aes256_sha256.pem"), "wrong", ErrIncorrectPassphrase},
+		{"legacy encryption", readTestdata(t, "legacy.pem"), "ceffu", ErrUnsupportedKey},
+		{"ECDSA PKCS #8", readTestdata(t, "ec.pem"), "", ErrUnsupportedKey},
+		{"ECDSA SEC 1", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}), "", ErrUnsupportedKey},
+		{"public key", readTestdata(t, "public.pem"), "", ErrUnsupportedKey},
+		{"no private key", certificate, "", ErrUnsupportedKey},
+		{"invalid base64", []byte("MIIEv*QIBADANBgkqhkiG9w0BAQEFAASC"), "", ErrUnsupportedKey},
+		{"base64 of garbage", []byte(base64.StdEncoding.EncodeToString([]byte("not a key"))), "", ErrUnsupportedKey},
+		{"truncated PEM", readTestdata(t, "rsa.pem")[:100], "", ErrUnsupportedKey},
+	}
+	for _, tt := range tests {
+		key, err := ParsePrivateKey(tt.data, []byte(tt.passphrase))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: ParsePrivateKey = %v, %v, want %v", tt.name, key != nil, err, tt.want)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	privateKey, err := ParsePrivateKey(readTestdata(t, "rsa.pem"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &privateKey.PublicKey
+	pkix, err := x509.MarshalPKIXPublicKey(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"PKIX PEM", readTestdata(t, "public.pem")},
+		{"PKCS #1 PEM", pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(want)})},
+		{"PKIX DER", pkix},
+		{"PKIX base64", []byte(base64.StdEncoding.EncodeToString(pkix))},
+	}
+	for _, tt := range tests {
+		key, err := ParsePublicKey(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !key.Equal(want) {
+			t.Errorf("%s: parsed another key", tt.name)
+		}
+	}
+
+	ecPKIX, err := x509.MarshalPKIXPublicKey(&ecTestKey(t).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"ECDSA":          ecPKIX,
+		"invalid base64": []byte("not base64!"),
+	} {
+		if _, err := ParsePublicKey(data); !errors.Is(err, ErrUnsupportedKey) {
+			t.Errorf("%s: ParsePublicKey = %v, want ErrUnsupportedKey", name, err)
+		}
+	}
+}
+
+// ecTestKey returns the ECDSA key of testdata, which ParsePrivateKey rejects.
+func ecTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	block, _ := pem.Decode(readTestdata(t, "ec.pem"))
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.(*ecdsa.PrivateKey)
+}
+
+func TestLoadPrivateKeyEnv(t *testing.T) {
+	escaped := strings.ReplaceAll(string(readTestdata(t, "aes128_sha1.pem")), "\n", `\n`)
+	t.Setenv("CEFFU_TEST_PRIVATE_KEY", escaped)
+	if _, err := LoadPrivateKeyEnv("CEFFU_TEST_PRIVATE_KEY", []byte("ceffu")); err != nil {
+		t.Errorf("escaped PEM: %v", err)
+	}
+	if _, err := LoadPrivateKeyEnv("CEFFU_TEST_UNSET", nil); err == nil {
+		t.Error("unset variable: no error")
+	}
+}
+
+func TestSigners(t *testing.T) {
+	privateKey, err := LoadPrivateKeyFile("testdata/rsa.pem", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"walletId":1}`)
+	want, err := NewRSASigner(privateKey).Sign(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PKCS #1 v1.5 signatures are deterministic
+	if got, err := NewCryptoSigner(privateKey).Sign(context.Background(), payload); err != nil || got != want {
+		t.Errorf("CryptoSigner.Sign = %s, %v, want %s", got, err, want)
+	}
+
+	if _, err := NewCryptoSigner(ecTestKey(t)).Sign(context.Background(), payload); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("CryptoSigner.Sign with an ECDSA key = %v, want ErrUnsupportedKey", err)
+	}
+	if _, err := New("key", string(readTestdata(t, "ec.pem")), Options{}); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("New with an ECDSA secret = %v, want ErrUnsupportedKey", err)
+	}
+}
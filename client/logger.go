@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Logger is the structured logger of the client. It is satisfied by *slog.Logger,
+// args being alternating keys and values.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// LogLevel is a level of the Logger.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota + 1
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	// LogLevelNone disables the messages.
+	LogLevelNone
+)
+
+// LogLevels are the levels the client logs its messages at. Zero fields take the level
+// of DefaultLogLevels.
+type LogLevels struct {
+	// Success is the level of the operations that succeeded, logged with the redacted request.
+	Success LogLevel
+	// Rejected is the level of the operations Ceffu responded to with an error code.
+	Rejected LogLevel
+	// Failed is the level of the operations that failed, e.g. on a transport error or
+	// an HTTP status other than 200.
+	Failed LogLevel
+	// Retry is the level of the retries of an operation.
+	Retry LogLevel
+}
+
+// DefaultLogLevels are the log levels of the client by default.
+var DefaultLogLevels = LogLevels{
+	Success:  LogLevelDebug,
+	Rejected: LogLevelWarn,
+	Failed:   LogLevelError,
+	Retry:    LogLevelInfo,
+}
+
+func (l LogLevels) withDefaults() LogLevels {
+	if l.Success == 0 {
+		l.Success = DefaultLogLevels.Success
+	}
+	if l.Rejected == 0 {
+		l.Rejected = DefaultLogLevels.Rejected
+	}
+	if l.Failed == 0 {
+		l.Failed = DefaultLogLevels.Failed
+	}
+	if l.Retry == 0 {
+		l.Retry = DefaultLogLevels.Retry
+	}
+	return l
+}
+
+// log logs the message at the level with the logger.
+func (l LogLevel) log(ctx context.Context, logger Logger, msg string, args ...interface{}) {
+	switch l {
+	case LogLevelDebug:
+		logger.DebugContext(ctx, msg, args...)
+	case LogLevelInfo:
+		logger.InfoContext(ctx, msg, args...)
+	case LogLevelWarn:
+		logger.WarnContext(ctx, msg, args...)
+	case LogLevelError:
+		logger.ErrorContext(ctx, msg, args...)
+	}
+}
+
+// logging returns the middleware logging every operation at the levels of LogLevels:
+// on success with the redacted request, when Ceffu returns an error code, and when
+// the request fails.
+func logging(logger Logger, levels LogLevels, redaction Redaction) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, op *Operation) (*Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(ctx, op)
+
+			args := []interface{}{
+				"operation", op.Name,
+				"method", op.Method,
+				"path", op.Path,
+				"latency", time.Since(start),
+			}
+			if requestID := op.Field("requestId"); requestID != "" {
+				args = append(args, "requestId", requestID)
+			}
+			if resp != nil {
+				args = append(args, "status", resp.StatusCode, "code", resp.Code)
+			}
+			switch {
+			case err != nil:
+				levels.Failed.log(ctx, logger, "ceffu request failed", append(args, "error", err.Error())...)
+			case resp.Code != SuccessCode:
+				levels.Rejected.log(ctx, logger, "ceffu request rejected", append(args, "body", truncate(RedactPayload(string(resp.Body), redaction)))...)
+			default:
+				if request, err := json.Marshal(op.Request); err == nil {
+					args = append(args, "request", RedactPayload(string(request), redaction))
+				}
+				levels.Success.log(ctx, logger, "ceffu request", args...)
+			}
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// recordingLogger records the messages as "level msg args".
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.record("debug", msg, args)
+}
+
+func (l *recordingLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.record("info", msg, args)
+}
+
+func (l *recordingLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	l.record("warn", msg, args)
+}
+
+func (l *recordingLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.record("error", msg, args)
+}
+
+// levels returns the level and message of the recorded messages, and clears them.
+func (l *recordingLogger) levels() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var levels []string
+	for _, message := range l.messages {
+		levels = append(levels, strings.SplitN(message, " [", 2)[0])
+	}
+	l.messages = nil
+	return levels
+}
+
+func TestLogLevels(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+
+	tests := []struct {
+		name   string
+		levels client.LogLevels
+		want   []string
+	}{
+		{"default", client.LogLevels{}, []string{
+			"debug ceffu request",
+			"warn ceffu request rejected",
+			"info ceffu request retried",
+			"error ceffu request failed",
+		}},
+		{"configured", client.LogLevels{Success: client.LogLevelInfo, Rejected: client.LogLevelError, Retry: client.LogLevelNone}, []string{
+			"info ceffu request",
+			"error ceffu request rejected",
+			"error ceffu request failed",
+		}},
+	}
+	for _, tt := range tests {
+		logger := &recordingLogger{}
+		c, err := srv.Client(client.Options{
+			Logger:    logger,
+			LogLevels: tt.levels,
+			RetryPolicy: &client.RetryPolicy{
+				MaxAttempts:          2,
+				InitialBackoff:       time.Millisecond,
+				RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); err != nil {
+			t.Fatal(err)
+		}
+		srv.InjectFault(ceffutest.Fault{Code: ceffutest.CodeWalletNotFound, Message: "wallet not found", Times: 1})
+		c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID)
+		srv.InjectFault(ceffutest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 2})
+		c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID)
+
+		got := logger.levels()
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: logged %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestErrorRedaction(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+	body := []byte(`{"code":"500","message":"failed","data":{"withdrawalAddress":"` + address + `","toWalletIdStr":"473690452895207426"}}`)
+
+	tests := []struct {
+		redaction client.Redaction
+		want      []string
+		hidden    []string
+	}{
+		{client.RedactPartial, []string{"0x52...9EE7", "4736...7426"}, []string{address, "473690452895207426"}},
+		{client.RedactFull, []string{"[REDACTED]"}, []string{address, "473690452895207426", "0x52...9EE7", "4736...7426"}},
+		{client.RedactNone, []string{address, "473690452895207426"}, nil},
+	}
+	for _, tt := range tests {
+		logger := &recordingLogger{}
+		c, err := srv.Client(client.Options{Logger: logger, Redaction: tt.redaction})
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.InjectFault(ceffutest.Fault{StatusCode: http.StatusInternalServerError, Body: body, Times: 1})
+		_, err = c.Withdrawal(context.Background(), &types.WithdrawalRequest{
+			WalletID:          walletID,
+			CoinSymbol:        "ETH",
+			Network:           "ETH",
+			Amount:            types.MustParseAmount("1"),
+			WithdrawalAddress: address,
+			ToWalletIDStr:     "473690452895207426",
+		})
+		var requestErr *client.RequestError
+		if !errors.As(err, &requestErr) {
+			t.Fatalf("redaction %d: %v", tt.redaction, err)
+		}
+		logger.mu.Lock()
+		logged := strings.Join(logger.messages, "\n")
+		logger.mu.Unlock()
+		for _, text := range []string{err.Error(), logged} {
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("redaction %d: %q does not contain %q", tt.redaction, text, want)
+				}
+			}
+			for _, hidden := range tt.hidden {
+				if strings.Contains(text, hidden) {
+					t.Errorf("redaction %d: %q contains %q", tt.redaction, text, hidden)
+				}
+			}
+		}
+	}
+}
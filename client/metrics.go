@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// ResultError is the result code of an operation that got no response, e.g. because
+// of a connection error or a timeout.
+const ResultError = "error"
+
+// Metrics receives the measures of the client, e.g. to export them to Prometheus.
+// Its methods are called synchronously and must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called once per operation, after its retries, with its result
+	// code: the API code of the response, its HTTP status code if it has no API code,
+	// or ResultError.
+	ObserveRequest(operation, path, code string, latency time.Duration)
+	// IncRetry is called before every retry of an operation.
+	IncRetry(operation, path string)
+	// ObserveRateLimitWait is called with the time every attempt waited for the rate limiter.
+	ObserveRateLimitWait(path string, wait time.Duration)
+	// IncSignFailure is called when a request cannot be signed.
+	IncSignFailure(path string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, string, string, time.Duration) {}
+func (nopMetrics) IncRetry(string, string)                              {}
+func (nopMetrics) ObserveRateLimitWait(string, time.Duration)           {}
+func (nopMetrics) IncSignFailure(string)                                {}
+
+// measuring returns the middleware observing the result and latency of every operation.
+func measuring(metrics Metrics) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, op *Operation) (*Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(ctx, op)
+			metrics.ObserveRequest(op.Name, op.Path, ResultCode(resp), time.Since(start))
+			return resp, err
+		})
+	}
+}
+
+// ResultCode returns the result code of a response as reported to Metrics.
+func ResultCode(resp *Response) string {
+	switch {
+	case resp == nil:
+		return ResultError
+	case resp.Code != "":
+		return resp.Code
+	default:
+		return strconv.Itoa(resp.StatusCode)
+	}
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Operation is a logical API call, passed through the middlewares of the client.
+type Operation struct {
+	// Name is the name of the operation, see OperationName.
+	Name   string
+	Method string
+	Path   string
+	// Request is the typed request, e.g. *types.WithdrawalRequest. Its timestamp is set
+	// when the request is signed.
+	Request interface{}
+}
+
+// Field returns the field of the request tagged with the json name, e.g. "walletId",
+// formatted as a string, or "" if the request has no such field or it is zero.
+func (op *Operation) Field(name string) string {
+	val := reflect.ValueOf(op.Request)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return ""
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return ""
+	}
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		if strings.Split(typ.Field(i).Tag.Get("json"), ",")[0] != name {
+			continue
+		}
+		field := val.Field(i)
+		if !field.CanInterface() || field.IsZero() {
+			return ""
+		}
+		if value, ok, err := formatValue(field); err == nil && ok {
+			return value
+		}
+		return ""
+	}
+	return ""
+}
+
+// Response is the raw response to an operation. When the request is retried, it is
+// the response to the last attempt.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Code and Message are the API response code and message decoded from Body,
+	// Code being SuccessCode on success.
+	Code    string
+	Message string
+}
+
+// RoundTripper executes an operation. The innermost RoundTripper signs and sends the
+// request, with retries and rate limiting. The error is a *RequestError when the
+// request fails or its HTTP status is not 200, while the API code of a response with
+// status 200 is only checked by the methods of Client, after the middlewares.
+type RoundTripper interface {
+	RoundTrip(ctx context.Context, op *Operation) (*Response, error)
+}
+
+// RoundTripperFunc adapts a function to a RoundTripper.
+type RoundTripperFunc func(ctx context.Context, op *Operation) (*Response, error)
+
+func (f RoundTripperFunc) RoundTrip(ctx context.Context, op *Operation) (*Response, error) {
+	return f(ctx, op)
+}
+
+// Middleware wraps a RoundTripper, e.g. to log, measure or trace the operations, or to
+// return canned responses in tests without calling next. A middleware returning neither
+// a response nor an error fails the operation with ErrNilResponse:
+//
+//	func Audit(next client.RoundTripper) client.RoundTripper {
+//		return client.RoundTripperFunc(func(ctx context.Context, op *client.Operation) (*client.Response, error) {
+//			resp, err := next.RoundTrip(ctx, op)
+//			audit(op.Name, op.Request, resp, err)
+//			return resp, err
+//		})
+//	}
+type Middleware func(next RoundTripper) RoundTripper
+
+// ErrNilResponse is the error of an operation when a middleware returned neither a
+// response nor an error.
+var ErrNilResponse = errors.New("ceffu: middleware returned no response and no error")
+
+// chain wraps rt with the middlewares, the first one being the outermost. Every
+// RoundTripper of the chain returns either a response or an error, so that the
+// middlewares never see a nil response without error.
+func chain(rt RoundTripper, middlewares []Middleware) RoundTripper {
+	rt = checkResponse(rt)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = checkResponse(middlewares[i](rt))
+	}
+	return rt
+}
+
+// checkResponse replaces a nil response without error of rt with ErrNilResponse.
+func checkResponse(rt RoundTripper) RoundTripper {
+	return RoundTripperFunc(func(ctx context.Context, op *Operation) (*Response, error) {
+		resp, err := rt.RoundTrip(ctx, op)
+		if resp == nil && err == nil {
+			return nil, NewRequestError(op.Path, WithMethod(op.Method), WithError(ErrNilResponse))
+		}
+		return resp, err
+	})
+}
+
+var operationNames = map[string]string{
+	PathCreateSubWallet:            "CreateSubWallet",
+	PathGetDepositAddress:          "GetDepositAddress",
+	PathDepositHistory:             "GetDepositHistory",
+	PathTransfer:                   "Transfer",
+	PathWithdrawal:                 "Withdrawal",
+	PathWithdrawalDetail:           "WithdrawalDetail",
+	PathTransferWithExchange:       "TransferWithExchange",
+	PathTransferDetailWithExchange: "TransferDetailWithExchange",
+}
+
+// OperationName returns the name of the operation of a path, e.g. "Withdrawal" for
+// PathWithdrawal, or the path itself if it is unknown.
+func OperationName(path string) string {
+	if name, ok := operationNames[path]; ok {
+		return name
+	}
+	return path
+}
@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {}
+func (nopLogger) InfoContext(ctx context.Context, msg string, args ...interface{})  {}
+func (nopLogger) WarnContext(ctx context.Context, msg string, args ...interface{})  {}
+func (nopLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {}
+
+func TestMiddlewares(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+
+	var calls []string
+	// observe relies on a response when there is no error, as the built-in middlewares do
+	observe := func(next client.RoundTripper) client.RoundTripper {
+		return client.RoundTripperFunc(func(ctx context.Context, op *client.Operation) (*client.Response, error) {
+			resp, err := next.RoundTrip(ctx, op)
+			if err == nil {
+				calls = append(calls, op.Name+" "+resp.Code)
+			} else {
+				calls = append(calls, op.Name+" failed")
+			}
+			return resp, err
+		})
+	}
+	canned := false
+	stub := func(next client.RoundTripper) client.RoundTripper {
+		return client.RoundTripperFunc(func(ctx context.Context, op *client.Operation) (*client.Response, error) {
+			if canned {
+				return nil, nil
+			}
+			return next.RoundTrip(ctx, op)
+		})
+	}
+	c, err := srv.Client(client.Options{
+		Middlewares: []client.Middleware{observe, stub},
+		Logger:      nopLogger{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); err != nil {
+		t.Fatal(err)
+	}
+	canned = true
+	if _, err := c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID); !errors.Is(err, client.ErrNilResponse) {
+		t.Errorf("nil response of a middleware: %v, want ErrNilResponse", err)
+	}
+	want := []string{"GetDepositAddress " + client.SuccessCode, "GetDepositAddress failed"}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("observed %q, want %q", calls, want)
+	}
+
+	// the body of a response with another HTTP status is kept in the error only
+	canned = false
+	srv.InjectFault(ceffutest.Fault{StatusCode: http.StatusBadGateway, Body: []byte("upstream unavailable"), Times: 1})
+	_, err = c.GetDepositAddress(context.Background(), "ETH", "ETH", walletID)
+	var requestErr *client.RequestError
+	if !errors.As(err, &requestErr) || requestErr.StatusCode != http.StatusBadGateway || string(requestErr.Body) != "upstream unavailable" {
+		t.Errorf("status 502: %v", err)
+	}
+}
@@ -43,3 +43,21 @@ func WithError(err error) ErrorOption {
 		ere.Err = err
 	}
 }
+
+func WithStatusCode(statusCode int) ErrorOption {
+	return func(ere *RequestError) {
+		ere.StatusCode = statusCode
+	}
+}
+
+func WithRedaction(redaction Redaction) ErrorOption {
+	return func(ere *RequestError) {
+		ere.Redaction = redaction
+	}
+}
+
+func WithErrorCodes(codes map[string]error) ErrorOption {
+	return func(ere *RequestError) {
+		ere.ErrorCodes = codes
+	}
+}
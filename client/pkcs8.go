@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Object identifiers of the encryption of PKCS #8 private keys, see RFC 8018.
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// maxPBKDF2Iterations bounds the iteration count of encrypted keys, so that a malformed
+// key cannot make the key derivation run for hours. openssl uses 2048 by default.
+const maxPBKDF2Iterations = 10000000
+
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// privateKeyInfo is the structure of a PKCS #8 private key, whatever its algorithm.
+type privateKeyInfo struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// decryptPKCS8 decrypts a PKCS #8 EncryptedPrivateKeyInfo encrypted with PBES2, the scheme
+// used by openssl pkcs8 -topk8, and returns the DER of the PKCS #8 private key. The key is
+// not parsed: ParsePrivateKey then only accepts RSA keys. It fails with
+// ErrIncorrectPassphrase if the decrypted data is not a PKCS #8 private key.
+func decryptPKCS8(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+	if !info.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("%w: encryption algorithm %s", ErrUnsupportedKey, info.EncryptionAlgorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.EncryptionAlgorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("%w: key derivation function %s", ErrUnsupportedKey, params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+	if kdf.IterationCount < 1 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: PBKDF2 iteration count %d", ErrUnsupportedKey, kdf.IterationCount)
+	}
+
+	var prf func() hash.Hash
+	switch algorithm := kdf.PRF.Algorithm; {
+	case len(algorithm) == 0, algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case algorithm.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("%w: pseudorandom function %s", ErrUnsupportedKey, algorithm)
+	}
+
+	var keyLength int
+	var newCipher func(key []byte) (cipher.Block, error)
+	switch algorithm := params.EncryptionScheme.Algorithm; {
+	case algorithm.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case algorithm.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case algorithm.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case algorithm.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("%w: encryption scheme %s", ErrUnsupportedKey, algorithm)
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != keyLength {
+		return nil, fmt.Errorf("%w: PBKDF2 key length %d for a %d bytes key", ErrUnsupportedKey, kdf.KeyLength, keyLength)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+
+	block, err := newCipher(pbkdf2.Key(passphrase, kdf.Salt, kdf.IterationCount, keyLength, prf))
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: invalid encrypted data", ErrUnsupportedKey)
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// remove the PKCS #7 padding, invalid when the passphrase is incorrect
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() ||
+		!bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrIncorrectPassphrase
+	}
+	decrypted = decrypted[:len(decrypted)-padding]
+
+	// the padding of data decrypted with an incorrect passphrase may still be valid
+	var key privateKeyInfo
+	if rest, err := asn1.Unmarshal(decrypted, &key); err != nil || len(rest) != 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+	return decrypted, nil
+}
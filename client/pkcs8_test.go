@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptPKCS8 encrypts data with AES-256-CBC and HMAC-SHA256 like openssl pkcs8 -topk8,
+// with the given PBKDF2 iteration count and key length parameter.
+func encryptPKCS8(t *testing.T, data, passphrase []byte, iterations, keyLength int) []byte {
+	t.Helper()
+	salt := []byte("saltsalt")
+	iv := bytes.Repeat([]byte{1}, aes.BlockSize)
+	derivation := iterations
+	if derivation > 2048 {
+		derivation = 2048 // the key is rejected before its derivation
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, derivation, 32, sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	encrypted := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	marshal := func(value interface{}) asn1.RawValue {
+		der, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asn1.RawValue{FullBytes: der}
+	}
+	kdf := pbkdf2Params{
+		Salt:           salt,
+		IterationCount: iterations,
+		KeyLength:      keyLength,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	}
+	params := pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: marshal(kdf)},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: marshal(iv)},
+	}
+	info := encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: marshal(params)},
+		EncryptedData:       encrypted,
+	}
+	return marshal(info).FullBytes
+}
+
+func TestDecryptPKCS8(t *testing.T) {
+	key, err := ParsePrivateKey(readTestdata(t, "pkcs8.pem"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("ceffu")
+
+	tests := []struct {
+		name       string
+		der        []byte
+		passphrase []byte
+		want       error
+	}{
+		{"valid", encryptPKCS8(t, pkcs8, passphrase, 2048, 0), passphrase, nil},
+		{"matching key length", encryptPKCS8(t, pkcs8, passphrase, 2048, 32), passphrase, nil},
+		{"key length of AES-128", encryptPKCS8(t, pkcs8, passphrase, 2048, 16), passphrase, ErrUnsupportedKey},
+		{"no iterations", encryptPKCS8(t, pkcs8, passphrase, 0, 0), passphrase, ErrUnsupportedKey},
+		{"too many iterations", encryptPKCS8(t, pkcs8, passphrase, maxPBKDF2Iterations+1, 0), passphrase, ErrUnsupportedKey},
+		{"not a private key", encryptPKCS8(t, []byte("valid padding, not DER"), passphrase, 2048, 0), passphrase, ErrIncorrectPassphrase},
+		{"trailing data", encryptPKCS8(t, append(pkcs8, 0), passphrase, 2048, 0), passphrase, ErrIncorrectPassphrase},
+	}
+	for _, tt := range tests {
+		der, err := decryptPKCS8(tt.der, tt.passphrase)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: decryptPKCS8 = %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if err == nil && !bytes.Equal(der, pkcs8) {
+			t.Errorf("%s: decrypted another key", tt.name)
+		}
+	}
+}
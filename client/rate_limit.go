@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitPath is the key of Options.RateLimits whose limit applies to the
+// paths without a limit of their own.
+const DefaultRateLimitPath = ""
+
+// RateLimit is the client-side limit of an endpoint: Rate requests per second on
+// average, with bursts of up to Burst requests. A Rate of 0 disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// rateLimiter paces the requests of a client with a token bucket per path.
+//
+// The rate of a path is halved (down to an eighth of its limit) every time Ceffu
+// throttles it, and grows back by a tenth of its limit on every successful response.
+// A Retry-After header blocks the path until the given time.
+type rateLimiter struct {
+	limits map[string]RateLimit
+	// errorCodes are the Options.ErrorCodes of the client, mapping the codes of
+	// throttled responses to ErrRateLimited.
+	errorCodes map[string]error
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	limit  RateLimit
+	rate   float64
+	tokens float64
+	// last is the time tokens were last added. It is in the future while the path is blocked.
+	last time.Time
+}
+
+func newRateLimiter(limits map[string]RateLimit, errorCodes map[string]error) *rateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		limits:     limits,
+		errorCodes: errorCodes,
+		buckets:    make(map[string]*bucket),
+	}
+}
+
+// bucket returns the bucket of the path, or nil if the path is not limited.
+// It must be called with l.mu held.
+func (l *rateLimiter) bucket(path string, now time.Time) *bucket {
+	if b, ok := l.buckets[path]; ok {
+		return b
+	}
+	limit, ok := l.limits[path]
+	if !ok {
+		limit = l.limits[DefaultRateLimitPath]
+	}
+	var b *bucket
+	if limit.Rate > 0 {
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		b = &bucket{limit: limit, rate: limit.Rate, tokens: float64(limit.Burst), last: now}
+	}
+	l.buckets[path] = b
+	return b
+}
+
+// wait blocks until a request to the path is allowed. It fails immediately with an error
+// wrapping ErrRateLimited if the request would not be allowed before the ctx deadline.
+func (l *rateLimiter) wait(ctx context.Context, path string) error {
+	now := time.Now()
+	l.mu.Lock()
+	b := l.bucket(path, now)
+	if b == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := b.reserve(now)
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		b.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("%w: waiting %s for %s would exceed the context deadline", ErrRateLimited, delay, path)
+	}
+	l.mu.Unlock()
+
+	for delay > 0 {
+		if err := sleep(ctx, delay); err != nil {
+			l.mu.Lock()
+			b.tokens++
+			l.mu.Unlock()
+			return err
+		}
+		// the path may have been blocked by a throttled response in the meantime
+		l.mu.Lock()
+		delay = b.last.Sub(time.Now())
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// observe adapts the rate of the path to the response of an attempt.
+func (l *rateLimiter) observe(path string, resp *Response) {
+	if resp == nil {
+		return
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(path, now)
+	if b == nil {
+		return
+	}
+	retryAfter := retryAfter(resp)
+	if retryAfter == 0 && resp.StatusCode != http.StatusTooManyRequests && (resp.Code == "" || !errors.Is(l.errorCodes[resp.Code], ErrRateLimited)) {
+		if resp.StatusCode == http.StatusOK {
+			b.setRate(now, b.rate+b.limit.Rate/10)
+		}
+		return
+	}
+
+	b.setRate(now, b.rate/2)
+	if retryAfter == 0 {
+		retryAfter = time.Duration(float64(time.Second) / b.rate)
+	}
+	b.block(now.Add(retryAfter))
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.advance(now)
+	b.tokens--
+
+	var delay time.Duration
+	if b.last.After(now) {
+		delay = b.last.Sub(now)
+	}
+	if b.tokens < 0 {
+		delay += time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	return delay
+}
+
+// advance adds the tokens earned since the last call.
+func (b *bucket) advance(now time.Time) {
+	if !now.After(b.last) {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if burst := float64(b.limit.Burst); b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+}
+
+func (b *bucket) setRate(now time.Time, rate float64) {
+	b.advance(now)
+	if floor := b.limit.Rate / 8; rate < floor {
+		rate = floor
+	}
+	if rate > b.limit.Rate {
+		rate = b.limit.Rate
+	}
+	b.rate = rate
+}
+
+// block stops adding tokens until the given time, and drops the available ones.
+func (b *bucket) block(until time.Time) {
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+	if until.After(b.last) {
+		b.last = until
+	}
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPacing(t *testing.T) {
+	l := newRateLimiter(map[string]RateLimit{DefaultRateLimitPath: {Rate: 20, Burst: 2}}, nil)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.wait(ctx, PathWithdrawal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the burst is immediate, the other 4 requests are paced at 20 per second
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond || elapsed > time.Second {
+		t.Errorf("6 requests took %s, want about 200ms", elapsed)
+	}
+
+	// the burst is earned back after an idle period
+	time.Sleep(100 * time.Millisecond)
+	start = time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.wait(ctx, PathWithdrawal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("burst took %s", elapsed)
+	}
+}
+
+func TestRateLimiterPaths(t *testing.T) {
+	if newRateLimiter(nil, nil) != nil {
+		t.Error("rate limiter without limits")
+	}
+	l := newRateLimiter(map[string]RateLimit{
+		PathWithdrawal:        {Rate: 1, Burst: 1},
+		PathGetDepositAddress: {Rate: 0},
+	}, nil)
+	now := time.Now()
+	if b := l.bucket(PathTransfer, now); b != nil {
+		t.Error("path without a limit is limited")
+	}
+	if b := l.bucket(PathGetDepositAddress, now); b != nil {
+		t.Error("path with a zero rate is limited")
+	}
+	b := l.bucket(PathWithdrawal, now)
+	if b == nil || b.limit.Burst != 1 || b.rate != 1 {
+		t.Fatalf("bucket = %+v", b)
+	}
+	if d := b.reserve(now); d != 0 {
+		t.Errorf("first request delayed %s", d)
+	}
+	if d := b.reserve(now); d != time.Second {
+		t.Errorf("second request delayed %s, want 1s", d)
+	}
+	if d := b.reserve(now.Add(time.Second)); d != time.Second {
+		t.Errorf("third request delayed %s, want 1s", d)
+	}
+}
+
+func TestRateLimiterThrottled(t *testing.T) {
+	errCode := errors.New("too many requests")
+	l := newRateLimiter(map[string]RateLimit{DefaultRateLimitPath: {Rate: 16, Burst: 16}}, map[string]error{
+		"429001": ErrRateLimited,
+		"400001": errCode,
+	})
+	rate := func() float64 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.buckets[PathWithdrawal].rate
+	}
+	l.wait(context.Background(), PathWithdrawal)
+
+	tests := []struct {
+		name string
+		resp *Response
+		rate float64
+	}{
+		{"429", &Response{StatusCode: http.StatusTooManyRequests}, 8},
+		{"rate limited code", &Response{StatusCode: http.StatusOK, Code: "429001"}, 4},
+		{"other code", &Response{StatusCode: http.StatusOK, Code: "400001"}, 4 + 1.6},
+		{"server error", &Response{StatusCode: http.StatusInternalServerError}, 5.6},
+		{"429 again", &Response{StatusCode: http.StatusTooManyRequests}, 2.8},
+		// the rate is not halved below an eighth of the limit
+		{"floor", &Response{StatusCode: http.StatusTooManyRequests}, 2},
+		{"200", &Response{StatusCode: http.StatusOK, Code: SuccessCode}, 3.6},
+		{"no response", nil, 3.6},
+	}
+	for _, tt := range tests {
+		l.observe(PathWithdrawal, tt.resp)
+		if got := rate(); math.Abs(got-tt.rate) > 1e-9 {
+			t.Errorf("%s: rate = %v, want %v", tt.name, got, tt.rate)
+		}
+	}
+
+	// the rate recovers gradually up to the limit
+	for i := 0; i < 7; i++ {
+		l.observe(PathWithdrawal, &Response{StatusCode: http.StatusOK})
+	}
+	if got := rate(); math.Abs(got-14.8) > 1e-9 {
+		t.Errorf("rate = %v after 7 successes, want 14.8", got)
+	}
+	for i := 0; i < 2; i++ {
+		l.observe(PathWithdrawal, &Response{StatusCode: http.StatusOK})
+	}
+	if got := rate(); got != 16 {
+		t.Errorf("rate = %v, want the limit 16", got)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	l := newRateLimiter(map[string]RateLimit{DefaultRateLimitPath: {Rate: 100, Burst: 100}}, nil)
+	ctx := context.Background()
+	if err := l.wait(ctx, PathWithdrawal); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"1"}}}
+	l.observe(PathWithdrawal, resp)
+	l.mu.Lock()
+	b := l.buckets[PathWithdrawal]
+	blocked, tokens := time.Until(b.last), b.tokens
+	l.mu.Unlock()
+	if blocked < 900*time.Millisecond || blocked > time.Second {
+		t.Errorf("path blocked for %s, want 1s", blocked)
+	}
+	if tokens > 0 {
+		t.Errorf("%v tokens left while blocked", tokens)
+	}
+
+	// other paths are not blocked
+	start := time.Now()
+	if err := l.wait(ctx, PathTransfer); err != nil || time.Since(start) > 20*time.Millisecond {
+		t.Errorf("other path: %v after %s", err, time.Since(start))
+	}
+	if err := l.wait(ctx, PathWithdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("request sent after %s, before the Retry-After delay", elapsed)
+	}
+}
+
+func TestRateLimiterDeadline(t *testing.T) {
+	l := newRateLimiter(map[string]RateLimit{DefaultRateLimitPath: {Rate: 1, Burst: 1}}, nil)
+	if err := l.wait(context.Background(), PathWithdrawal); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := l.wait(ctx, PathWithdrawal)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("wait = %v, want ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("wait failed after %s, want immediately", elapsed)
+	}
+
+	// a canceled wait gives its token back
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if err := l.wait(ctx, PathWithdrawal); err != context.Canceled {
+		t.Errorf("wait = %v, want context.Canceled", err)
+	}
+	l.mu.Lock()
+	tokens := l.buckets[PathWithdrawal].tokens
+	l.mu.Unlock()
+	if tokens < -0.5 || tokens > 0.5 {
+		t.Errorf("%v tokens after the failed waits, want about 0", tokens)
+	}
+}
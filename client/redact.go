@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Redaction is how sensitive values are hidden from logs and error messages.
+type Redaction int
+
+const (
+	// RedactPartial masks addresses and API keys but their first and last 4 characters,
+	// and hides signatures entirely.
+	RedactPartial Redaction = iota
+	// RedactFull hides addresses, API keys and signatures entirely.
+	RedactFull
+	// RedactNone hides nothing. It should only be used for debugging.
+	RedactNone
+)
+
+const redacted = "[REDACTED]"
+
+// maxErrorPayload is the length params and bodies are truncated to in error messages.
+const maxErrorPayload = 512
+
+// secretFields are hidden entirely unless Redaction is RedactNone.
+var secretFields = map[string]bool{
+	"signature": true,
+	"sign":      true,
+	"encoded":   true,
+}
+
+// maskedFields are masked according to the Redaction.
+var maskedFields = map[string]bool{
+	"address":           true,
+	"withdrawalAddress": true,
+	"toWalletIdStr":     true,
+	"walletAddress":     true,
+	"toAddress":         true,
+	"fromAddress":       true,
+	"memo":              true,
+	"apiKey":            true,
+	"open-apikey":       true,
+}
+
+// Mask hides a sensitive value according to the redaction level.
+func (r Redaction) Mask(value string) string {
+	switch {
+	case r == RedactNone || value == "":
+		return value
+	case r == RedactPartial && len(value) > 12:
+		return value[:4] + "..." + value[len(value)-4:]
+	default:
+		return redacted
+	}
+}
+
+func (r Redaction) redact(key, value string) string {
+	switch {
+	case secretFields[key] && r != RedactNone:
+		return redacted
+	case maskedFields[key]:
+		return r.Mask(value)
+	}
+	return value
+}
+
+// RedactPayload hides the sensitive fields of a JSON body or a query string, e.g. the
+// addresses of a withdrawal. Other payloads are returned unchanged.
+func RedactPayload(payload string, r Redaction) string {
+	if r == RedactNone || payload == "" {
+		return payload
+	}
+
+	trimmed := strings.TrimSpace(payload)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		decoder := json.NewDecoder(strings.NewReader(trimmed))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return payload
+		}
+		buf := &bytes.Buffer{}
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(r.redactJSON(v)); err != nil {
+			return payload
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+
+	if !strings.Contains(payload, "=") {
+		return payload
+	}
+	values, err := url.ParseQuery(payload)
+	if err != nil {
+		return payload
+	}
+	for key, vs := range values {
+		for i := range vs {
+			vs[i] = r.redact(key, vs[i])
+		}
+	}
+	return values.Encode()
+}
+
+func (r Redaction) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				v[key] = r.redact(key, s)
+				continue
+			}
+			v[key] = r.redactJSON(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redactJSON(value)
+		}
+	}
+	return v
+}
+
+// truncate shortens s to maxErrorPayload bytes.
+func truncate(s string) string {
+	if len(s) <= maxErrorPayload {
+		return s
+	}
+	return s[:maxErrorPayload] + "...(truncated)"
+}
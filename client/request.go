@@ -1,23 +1,20 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
-	"reflect"
-	"strconv"
 	"strings"
 	"time"
 )
 
+// defaultHTTPTimeout is the timeout of the http.Client of New when Options.HttpClient is nil.
 const defaultHTTPTimeout = 20 * time.Second
 
-func (c *client) request(ctx context.Context, path, method string, headers http.Header, body io.Reader) ([]byte, error) {
+func (c *client) request(ctx context.Context, path, method string, headers http.Header, body io.Reader) (*Response, error) {
 	request, err := http.NewRequestWithContext(ctx, method, path, body)
 	if err != nil {
 		return nil, err
@@ -38,90 +35,170 @@ func (c *client) request(ctx context.Context, path, method string, headers http.
 		defer resp.Body.Close()
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		var data []byte
-		if b, err := io.ReadAll(resp.Body); err == nil {
-			data = b
-		}
-
-		return nil, NewRequestError(
-			path,
-			WithCode(strconv.Itoa(resp.StatusCode)),
-			WithMessage(string(data)),
-			WithBody(data),
-		)
-	}
-
 	data, err := io.ReadAll(resp.Body)
-	if err != nil {
+	if err != nil && resp.StatusCode == http.StatusOK {
 		return nil, err
 	}
-	return data, nil
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+	}
+	response.Code, response.Message = decodeStatus(data)
+	if err != nil {
+		// the status is kept, with the part of the body that was read
+		return response, fmt.Errorf("reading the response body: %w", err)
+	}
+	return response, nil
+}
+
+// decodeStatus returns the API response code and message of a body, or "" if it is
+// not an API response.
+func decodeStatus(body []byte) (code, message string) {
+	resp := struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(body, &resp) != nil {
+		return "", ""
+	}
+	return resp.Code, resp.Message
 }
 
 func (c *client) Get(ctx context.Context, path string, params interface{}) ([]byte, error) {
-	encoded, err := URLEncode(params)
+	return c.do(ctx, http.MethodGet, path, params)
+}
+
+func (c *client) Post(ctx context.Context, path string, body interface{}) ([]byte, error) {
+	return c.do(ctx, http.MethodPost, path, body)
+}
+
+// do passes the operation through the middlewares of the client, and returns either
+// the body of the response or an error. The body of a response with an HTTP status
+// other than 200 is only kept in the Body of the *RequestError.
+func (c *client) do(ctx context.Context, method, path string, params interface{}) ([]byte, error) {
+	op := &Operation{
+		Name:    OperationName(path),
+		Method:  method,
+		Path:    path,
+		Request: params,
+	}
+	resp, err := c.roundTripper.RoundTrip(ctx, op)
 	if err != nil {
 		return nil, err
 	}
-	if encoded != "" {
-		path += "?" + encoded
-	}
-	signature, err := c.sign(encoded)
+	return resp.Body, nil
+}
 
-	headers := http.Header{
-		"Content-Type": []string{"application/json"},
-		"open-apikey":  []string{c.apiKey},
-		"signature":    []string{signature},
+// call sends the request, retrying it according to the retry policy of the client.
+// It is the innermost RoundTripper of the middleware chain.
+func (c *client) call(ctx context.Context, op *Operation) (*Response, error) {
+	method, path, params := op.Method, op.Path, op.Request
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, path, params)
+		if c.rateLimiter != nil {
+			c.rateLimiter.observe(path, resp)
+		}
+		if attempt >= c.retryPolicy.maxAttempts() || ctx.Err() != nil || !c.retryPolicy.shouldRetry(resp, err) ||
+			!idempotent(method, path, params) {
+			return resp, err
+		}
+
+		backoff := c.retryPolicy.backoff(attempt)
+		if retryAfter := retryAfter(resp); retryAfter > backoff {
+			backoff = retryAfter
+		}
+		c.metrics.IncRetry(op.Name, path)
+		if c.logger != nil {
+			args := []interface{}{"operation", op.Name, "path", path, "attempt", attempt, "backoff", backoff}
+			if resp != nil {
+				args = append(args, "status", resp.StatusCode, "code", resp.Code)
+			}
+			if err != nil {
+				args = append(args, "error", err.Error())
+			}
+			c.logLevels.Retry.log(ctx, c.logger, "ceffu request retried", args...)
+		}
+		if err := sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
 	}
-	return c.request(ctx, fmt.Sprintf("%s%s", c.domain, path), http.MethodGet, headers, nil)
 }
 
-func (c *client) Post(ctx context.Context, path string, body interface{}) ([]byte, error) {
-	data, err := json.Marshal(body)
+// encodePayload returns the payload signed by a request: the canonical query string of
+// GET requests, or the JSON body of POST requests.
+func encodePayload(method string, params interface{}) (string, error) {
+	if method == http.MethodGet {
+		return URLEncode(params)
+	}
+	data, err := json.Marshal(params)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	signature, err := c.sign(string(data))
+	return string(data), nil
+}
 
-	headers := http.Header{
-		"Content-Type": []string{"application/json"},
-		"open-apikey":  []string{c.apiKey},
-		"signature":    []string{signature},
+// send signs and sends a single attempt of the request. The timestamp of params is
+// refreshed before signing, so params should be a pointer to the request struct.
+// All errors are returned as *RequestError.
+func (c *client) send(ctx context.Context, method, path string, params interface{}) (*Response, error) {
+	if c.rateLimiter != nil {
+		start := time.Now()
+		err := c.rateLimiter.wait(ctx, path)
+		c.metrics.ObserveRateLimitWait(path, time.Since(start))
+		if err != nil {
+			return nil, c.newRequestError(path, WithMethod(method), WithError(err))
+		}
 	}
-	return c.request(ctx, fmt.Sprintf("%s%s", c.domain, path), http.MethodPost, headers, bytes.NewReader(data))
-}
+	setTimestamp(params, time.Now().UnixMilli())
 
-func URLEncode(s interface{}) (string, error) {
-	if s == nil {
-		return "", errors.New("provided value is nil")
+	payload, err := encodePayload(method, params)
+	if err != nil {
+		return nil, c.newRequestError(path, WithMethod(method), WithError(err))
+	}
+	var query string
+	var body io.Reader
+	if method == http.MethodGet {
+		if payload != "" {
+			query = "?" + payload
+		}
+	} else {
+		body = strings.NewReader(payload)
 	}
 
-	val := reflect.ValueOf(s)
-	if val.Kind() == reflect.Ptr {
-		val = val.Elem()
+	// the credentials may be rotated between attempts
+	credentials, err := c.credentials.Credentials(ctx)
+	if err == nil {
+		err = credentials.validate()
 	}
-	if val.Kind() != reflect.Struct {
-		return "", errors.New("provided value is not a struct")
+	if err != nil {
+		return nil, c.newRequestError(path, WithMethod(method), WithParams(payload), WithError(err))
+	}
+	signature, err := credentials.Signer.Sign(ctx, []byte(payload))
+	if err != nil {
+		c.metrics.IncSignFailure(path)
+		return nil, c.newRequestError(path, WithMethod(method), WithParams(payload), WithError(err))
 	}
 
-	typ := val.Type()
-	urls := url.Values{}
-	for i := 0; i < val.NumField(); i++ {
-		if !val.Field(i).CanInterface() {
-			continue
-		}
-		name := typ.Field(i).Name
-		tag := typ.Field(i).Tag.Get("json")
-		if tag != "" {
-			index := strings.Index(tag, ",")
-			if index == -1 {
-				name = tag
-			} else {
-				name = tag[:index]
-			}
-		}
-		urls.Set(name, fmt.Sprintf("%v", val.Field(i).Interface()))
+	headers := http.Header{
+		"Content-Type": []string{"application/json"},
+		"open-apikey":  []string{credentials.APIKey},
+		"signature":    []string{signature},
+	}
+	resp, err := c.request(ctx, fmt.Sprintf("%s%s%s", c.domain, path, query), method, headers, body)
+	if resp == nil {
+		return nil, c.newRequestError(path, WithMethod(method), WithParams(payload), WithError(err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, c.newRequestError(
+			path,
+			WithMethod(method),
+			WithParams(payload),
+			WithStatusCode(resp.StatusCode),
+			WithMessage(http.StatusText(resp.StatusCode)),
+			WithBody(resp.Body),
+			WithError(err),
+		)
 	}
-	return urls.Encode(), nil
+	return resp, nil
 }
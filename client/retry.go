@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried.
+//
+// Every attempt is signed again with a fresh timestamp, while the requestId of the
+// request is kept, so that retried Withdrawal and Transfer requests stay idempotent.
+// POST requests without a requestId are not retried, except the queries sent with POST
+// such as TransferDetailWithExchange.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each attempt.
+	Multiplier float64
+	// Jitter is the fraction (0-1) of the delay that is randomized.
+	Jitter float64
+	// RetryableStatusCodes are the HTTP status codes that are retried.
+	RetryableStatusCodes []int
+	// RetryableCodes are the API response codes that are retried.
+	RetryableCodes []string
+}
+
+// DefaultRetryPolicy returns a policy that retries up to 3 times on connection errors,
+// timeouts, 429 and 5xx responses, with exponential backoff starting at 200ms. Ceffu
+// does not document its API codes, so none is retried unless added to RetryableCodes.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// shouldRetry reports whether an attempt that ended with the given response and error is retried.
+func (p *RetryPolicy) shouldRetry(resp *Response, err error) bool {
+	if p == nil {
+		return false
+	}
+	switch {
+	case resp == nil:
+		// no response at all: only connection errors and timeouts are retried
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	case resp.StatusCode != http.StatusOK:
+		for _, code := range p.RetryableStatusCodes {
+			if code == resp.StatusCode {
+				return true
+			}
+		}
+	case len(p.RetryableCodes) > 0:
+		for _, retryable := range p.RetryableCodes {
+			if retryable == resp.Code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry (starting at 1).
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfter returns the delay requested by the Retry-After header of the response, in
+// seconds or as an HTTP date, or 0 if there is none.
+func retryAfter(resp *Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// postQueries are the paths of the POST requests that do not change any state.
+var postQueries = map[string]bool{
+	PathTransferDetailWithExchange: true,
+}
+
+// idempotent reports whether a request can be sent again: GET requests, POST queries,
+// and the other POST requests with a requestId.
+func idempotent(method, path string, params interface{}) bool {
+	if method != http.MethodPost || postQueries[path] {
+		return true
+	}
+	val := reflect.ValueOf(params)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	val = val.Elem()
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		if strings.Split(typ.Field(i).Tag.Get("json"), ",")[0] == "requestId" {
+			field := val.Field(i)
+			return field.Kind() == reflect.String && field.String() != ""
+		}
+	}
+	return false
+}
+
+// setTimestamp sets the field tagged `json:"timestamp"` of the struct pointed to by v.
+// Values that are not pointers to structs are left unchanged.
+func setTimestamp(v interface{}, timestamp int64) {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return
+	}
+	val = val.Elem()
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("json")
+		if strings.Split(tag, ",")[0] != "timestamp" {
+			continue
+		}
+		field := val.Field(i)
+		if field.CanSet() && field.Kind() == reflect.Int64 {
+			field.SetInt(timestamp)
+		}
+		return
+	}
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+type staticSigner struct{}
+
+func (staticSigner) Sign(_ context.Context, _ []byte) (string, error) {
+	return "signature", nil
+}
+
+// newRetryClient returns a client of a server answering with handler, and the number of
+// requests the server received.
+func newRetryClient(t *testing.T, policy *RetryPolicy, handler http.HandlerFunc) (*client, *int32) {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := New("key", "", Options{Domain: srv.URL, Signer: staticSigner{}, RetryPolicy: policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*client), &requests
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 500 * time.Millisecond, Multiplier: 2}
+	for retry, want := range []time.Duration{100, 200, 400, 500, 500} {
+		if got := p.backoff(retry + 1); got != want*time.Millisecond {
+			t.Errorf("backoff(%d) = %s, want %s", retry+1, got, want*time.Millisecond)
+		}
+	}
+
+	// a multiplier lower than 1 keeps the delay constant
+	p = &RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 0.5}
+	if got := p.backoff(3); got != 100*time.Millisecond {
+		t.Errorf("backoff(3) = %s, want 100ms", got)
+	}
+
+	// the jitter only shortens the delay, by up to Jitter of it
+	p = &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.2}
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 1000; i++ {
+		got := p.backoff(3)
+		if got < 800*time.Millisecond || got > time.Second {
+			t.Fatalf("backoff(3) = %s, want between 800ms and 1s", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("%d distinct delays in 1000, want randomized delays", len(seen))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:          2,
+		InitialBackoff:       time.Millisecond,
+		Multiplier:           1,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+	c, requests := newRetryClient(t, policy, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	start := time.Now()
+	_, err := c.Get(context.Background(), PathWithdrawalDetail, &types.WithdrawalDetailRequest{OrderViewID: "1"})
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("Get = %v, want ErrServer", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("%d attempts, want 2", n)
+	}
+	// Retry-After takes priority over the shorter backoff
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, before Retry-After", elapsed)
+	}
+}
+
+func TestRetryIdempotency(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusBadGateway},
+	}
+	c, requests := newRetryClient(t, policy, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		params   interface{}
+		attempts int32
+	}{
+		{"GET", http.MethodGet, PathWithdrawalDetail, &types.WithdrawalDetailRequest{OrderViewID: "1"}, 3},
+		{"POST with a request id", http.MethodPost, PathWithdrawal, &types.WithdrawalRequest{RequestID: "1"}, 3},
+		{"POST query", http.MethodPost, PathTransferDetailWithExchange, &types.TransferDetailWithExchangeRequest{OrderViewID: "1"}, 3},
+		{"POST without a request id", http.MethodPost, PathWithdrawal, &types.WithdrawalRequest{}, 1},
+		{"POST without a request id field", http.MethodPost, PathWithdrawal, map[string]string{"requestId": "1"}, 1},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(requests, 0)
+		if _, err := c.do(context.Background(), tt.method, tt.path, tt.params); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		if n := atomic.LoadInt32(requests); n != tt.attempts {
+			t.Errorf("%s: %d attempts, want %d", tt.name, n, tt.attempts)
+		}
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:          10,
+		InitialBackoff:       time.Second,
+		RetryableStatusCodes: []int{http.StatusBadGateway},
+	}
+	c, requests := newRetryClient(t, policy, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err := c.Get(ctx, PathWithdrawalDetail, &types.WithdrawalDetailRequest{OrderViewID: "1"})
+	if err != context.Canceled {
+		t.Errorf("Get = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Get returned after %s, want right after the cancellation", elapsed)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("%d attempts, want 1", n)
+	}
+}
+
+func TestResponseBodyReadError(t *testing.T) {
+	c, _ := newRetryClient(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		// the body is shorter than announced
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code":"500`))
+	})
+
+	_, err := c.Get(context.Background(), PathWithdrawalDetail, &types.WithdrawalDetailRequest{OrderViewID: "1"})
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("Get = %v, want a *RequestError", err)
+	}
+	if requestErr.StatusCode != http.StatusInternalServerError || requestErr.Err == nil || !errors.Is(err, ErrServer) {
+		t.Errorf("Get = %v, want the status and the read error", err)
+	}
+	if string(requestErr.Body) != `{"code":"500` {
+		t.Errorf("body = %q", requestErr.Body)
+	}
+}
+
+func TestDefaultHTTPClient(t *testing.T) {
+	c, err := New("key", "", Options{Signer: staticSigner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout := c.(*client).httpClient.Timeout; timeout != defaultHTTPTimeout {
+		t.Errorf("timeout = %s, want %s", timeout, defaultHTTPTimeout)
+	}
+}
@@ -5,23 +5,11 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
-	"crypto/sha512"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"sort"
 )
 
-func (c *client) sign(data string) (string, error) {
-	hashed := sha512.Sum512([]byte(data))
-	signature, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA512, hashed[:])
-	if err != nil {
-		return "", err
-	}
-
-	return base64.StdEncoding.EncodeToString(signature), nil
-}
-
 func Verify(publicKey *rsa.PublicKey, data map[string]interface{}, signBase64 string) (bool, error) {
 	delete(data, "encoded")
 	delete(data, "sign")
@@ -56,29 +44,15 @@ func Decode(privateKey *rsa.PrivateKey, data string) ([]byte, error) {
 	return decrypted, nil
 }
 
+// ParseRSAPrivateKey parses an RSA private key in any of the formats of ParsePrivateKey,
+// except encrypted PEM.
 func ParseRSAPrivateKey(privateKeyBase64 string) (*rsa.PrivateKey, error) {
-	decodedPrivateKey, err := base64.StdEncoding.DecodeString(privateKeyBase64)
-	if err != nil {
-		return nil, err
-	}
-	privateKey, err := x509.ParsePKCS1PrivateKey(decodedPrivateKey)
-	if err != nil {
-		return nil, err
-	}
-	return privateKey, nil
+	return ParsePrivateKey([]byte(privateKeyBase64), nil)
 }
 
+// ParseRSAPublicKey parses an RSA public key in any of the formats of ParsePublicKey.
 func ParseRSAPublicKey(publicKeyBase64 string) (*rsa.PublicKey, error) {
-	decodedPublicKey, err := base64.StdEncoding.DecodeString(publicKeyBase64)
-	if err != nil {
-		return nil, err
-	}
-	//publicKey, err := x509.ParsePKIXPublicKey(decodedPublicKey)
-	publicKey, err := x509.ParsePKCS1PublicKey(decodedPublicKey)
-	if err != nil {
-		return nil, err
-	}
-	return publicKey, nil
+	return ParsePublicKey([]byte(publicKeyBase64))
 }
 
 func sortKeys(data interface{}) interface{} {
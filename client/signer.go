@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Signer signs the payload of requests: the URL-encoded query of GET requests, or the
+// JSON body of POST requests. The signature is returned base64-encoded, as sent in the
+// signature header. Signers must be safe for concurrent use.
+//
+// Ceffu expects a SHA512withRSA (PKCS #1 v1.5) signature verifiable with the public key
+// registered for the API key.
+type Signer interface {
+	Sign(ctx context.Context, payload []byte) (string, error)
+}
+
+// RSASigner signs with an RSA private key held in memory. It is the default Signer of
+// New, built from the API key secret.
+type RSASigner struct {
+	privateKey *rsa.PrivateKey
+}
+
+func NewRSASigner(privateKey *rsa.PrivateKey) *RSASigner {
+	return &RSASigner{privateKey: privateKey}
+}
+
+func (s *RSASigner) Sign(_ context.Context, payload []byte) (string, error) {
+	hashed := sha512.Sum512(payload)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA512, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// CryptoSigner adapts a crypto.Signer of an RSA key, e.g. a PKCS #11 or cloud KMS key,
+// so that the private key never leaves its store. The crypto.Signer does not observe
+// the context. Signers of other key types fail, as Ceffu only documents RSA signatures.
+type CryptoSigner struct {
+	signer crypto.Signer
+}
+
+func NewCryptoSigner(signer crypto.Signer) *CryptoSigner {
+	return &CryptoSigner{signer: signer}
+}
+
+func (s *CryptoSigner) Sign(_ context.Context, payload []byte) (string, error) {
+	if s.signer == nil {
+		return "", errors.New("crypto signer is nil")
+	}
+	if _, ok := s.signer.Public().(*rsa.PublicKey); !ok {
+		return "", fmt.Errorf("%w: %T is not an RSA public key", ErrUnsupportedKey, s.signer.Public())
+	}
+	hashed := sha512.Sum512(payload)
+	signature, err := s.signer.Sign(rand.Reader, hashed[:], crypto.SHA512)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
@@ -9,38 +9,42 @@ import (
 )
 
 type SubWallet interface {
-	CreateSubWallet(ctx context.Context, parentWalletID, walletName string, autoCollection bool) (walletId int64, walletType uint32, err error)
+	CreateSubWallet(ctx context.Context, parentWalletID, walletName string, autoCollection bool, opts ...CallOption) (walletId int64, walletType uint32, err error)
 	GetDepositAddress(ctx context.Context, network, symbol string, walletID int64) (string, error)
 	GetDepositHistory(ctx context.Context, walletID int64, symbol, network string, startTime, endTime int64, pageNo, pageLimit int64) ([]*types.Transaction, error)
-	Transfer(ctx context.Context, symbol string, amount float64, fromWalletID, toWalletID int64) (*types.Transfer, error)
+	GetDepositHistoryPage(ctx context.Context, walletID int64, symbol, network string, startTime, endTime int64, pageNo, pageLimit int64) (*types.TransactionPage, error)
+	Transfer(ctx context.Context, symbol string, amount types.Amount, fromWalletID, toWalletID int64, opts ...CallOption) (*types.Transfer, error)
 }
 
 // CreateSubWallet This method allows to create Sub Wallet of the requested
 // Parent wallet ID (Only Applicable to Parent Wallet (Prime)).
 //
+// The request id is generated by the client unless provided with WithRequestID.
+//
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471342
-func (c *client) CreateSubWallet(ctx context.Context, parentWalletID, walletName string, autoCollection bool) (walletId int64, walletType uint32, err error) {
+func (c *client) CreateSubWallet(ctx context.Context, parentWalletID, walletName string, autoCollection bool, opts ...CallOption) (walletId int64, walletType uint32, err error) {
+	requestID, err := c.requestID(opts)
+	if err != nil {
+		return 0, 0, err
+	}
 	request := types.CreatSubWalletRequest{
 		ParentWalletID: parentWalletID,
 		WalletName:     walletName,
 		AutoCollection: types.ToAutoCollection(autoCollection),
-		RequestID:      c.RequestID.Generate(),
+		RequestID:      requestID,
 		Timestamp:      time.Now().UnixMilli(),
 	}
 
-	ret, err := c.Post(ctx, PathCreateSubWallet, request)
+	ret, err := c.Post(ctx, PathCreateSubWallet, &request)
 	if err != nil {
-		return 0, 0, NewRequestError(
-			PathCreateSubWallet,
-			WithError(err),
-		)
+		return 0, 0, err
 	}
 	response := types.CreatSubWalletResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return 0, 0, err
 	}
 	if response.Code != SuccessCode {
-		return 0, 0, NewRequestError(
+		return 0, 0, c.newRequestError(
 			PathCreateSubWallet,
 			WithCode(response.Code),
 			WithMessage(response.Message),
@@ -61,19 +65,16 @@ func (c *client) GetDepositAddress(ctx context.Context, network, symbol string,
 		WalletID:   walletID,
 	}
 
-	ret, err := c.Get(ctx, PathGetDepositAddress, request)
+	ret, err := c.Get(ctx, PathGetDepositAddress, &request)
 	if err != nil {
-		return "", NewRequestError(
-			PathGetDepositAddress,
-			WithError(err),
-		)
+		return "", err
 	}
 	response := types.GetDepositAddressResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return "", err
 	}
 	if response.Code != SuccessCode {
-		return "", NewRequestError(
+		return "", c.newRequestError(
 			PathGetDepositAddress,
 			WithCode(response.Code),
 			WithMessage(response.Message),
@@ -92,6 +93,19 @@ func (c *client) GetDepositAddress(ctx context.Context, network, symbol string,
 //
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471585
 func (c *client) GetDepositHistory(ctx context.Context, walletID int64, symbol, network string, startTime, endTime int64, pageNo, pageLimit int64) ([]*types.Transaction, error) {
+	page, err := c.GetDepositHistoryPage(ctx, walletID, symbol, network, startTime, endTime, pageNo, pageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return page.Data, nil
+}
+
+// GetDepositHistoryPage This method is the same as GetDepositHistory, but also returns the paging
+// information (totalPage, pageNo and pageLimit) of the response.
+// Use NewDepositHistoryIterator to walk all pages of a time range longer than 30 days.
+//
+// reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471585
+func (c *client) GetDepositHistoryPage(ctx context.Context, walletID int64, symbol, network string, startTime, endTime int64, pageNo, pageLimit int64) (*types.TransactionPage, error) {
 	request := types.GetDepositHistoryRequest{
 		WalletID:   walletID,
 		CoinSymbol: symbol,
@@ -103,56 +117,55 @@ func (c *client) GetDepositHistory(ctx context.Context, walletID int64, symbol,
 		Timestamp:  time.Now().UnixMilli(),
 	}
 
-	ret, err := c.Get(ctx, PathDepositHistory, request)
+	ret, err := c.Get(ctx, PathDepositHistory, &request)
 	if err != nil {
-		return nil, NewRequestError(
-			PathDepositHistory,
-			WithError(err),
-		)
+		return nil, err
 	}
 	response := types.GetDepositHistoryResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return nil, err
 	}
 	if response.Code != SuccessCode {
-		return nil, NewRequestError(
+		return nil, c.newRequestError(
 			PathDepositHistory,
 			WithCode(response.Code),
 			WithMessage(response.Message),
 		)
 	}
-
-	return response.Data.Data, nil
+	return &response.Data, nil
 }
 
 // Transfer This method allows to transfer asset between Sub Wallet and Prime Wallet Restriction:
 // Only applicable to Prime wallet structure.
 //
+// The request id is generated by the client unless provided with WithRequestID.
+//
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471348
-func (c *client) Transfer(ctx context.Context, symbol string, amount float64, fromWalletID, toWalletID int64) (*types.Transfer, error) {
+func (c *client) Transfer(ctx context.Context, symbol string, amount types.Amount, fromWalletID, toWalletID int64, opts ...CallOption) (*types.Transfer, error) {
+	requestID, err := c.requestID(opts)
+	if err != nil {
+		return nil, err
+	}
 	timestamp := time.Now().UnixMilli()
 	request := types.TransferRequest{
 		CoinSymbol:   symbol,
-		Amount:       amount,
+		Amount:       types.NumberAmount{Amount: amount},
 		FromWalletID: fromWalletID,
 		ToWalletID:   toWalletID,
-		RequestID:    c.RequestID.Generate(),
+		RequestID:    requestID,
 		Timestamp:    timestamp,
 	}
 
-	ret, err := c.Post(ctx, PathTransfer, request)
+	ret, err := c.Post(ctx, PathTransfer, &request)
 	if err != nil {
-		return nil, NewRequestError(
-			PathTransfer,
-			WithError(err),
-		)
+		return nil, err
 	}
 	response := types.TransferResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return nil, err
 	}
 	if response.Code != SuccessCode {
-		return nil, NewRequestError(
+		return nil, c.newRequestError(
 			PathTransfer,
 			WithCode(response.Code),
 			WithMessage(response.Message),
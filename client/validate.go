@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// ErrInvalidWithdrawal is matched with errors.Is by the errors of ValidateWithdrawal.
+var ErrInvalidWithdrawal = errors.New("ceffu: invalid withdrawal")
+
+// ValidationError is a withdrawal rejected by ValidateWithdrawal.
+type ValidationError struct {
+	Field  string
+	Reason string
+	Err    error // Cause, e.g. ErrAddressNotWhitelisted
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidWithdrawal, e.Field, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidWithdrawal
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// WhitelistChecker reports whether an address is in the withdrawal whitelist of a wallet,
+// e.g. from the whitelist kept by the caller.
+type WhitelistChecker interface {
+	IsWhitelisted(ctx context.Context, walletID int64, coinSymbol, network, address, memo string) (bool, error)
+}
+
+// WhitelistCheckerFunc is a function implementing WhitelistChecker.
+type WhitelistCheckerFunc func(ctx context.Context, walletID int64, coinSymbol, network, address, memo string) (bool, error)
+
+func (f WhitelistCheckerFunc) IsWhitelisted(ctx context.Context, walletID int64, coinSymbol, network, address, memo string) (bool, error) {
+	return f(ctx, walletID, coinSymbol, network, address, memo)
+}
+
+// CoinNetwork are the withdrawal rules of a coin on a network, see Options.CoinNetworks.
+type CoinNetwork struct {
+	CoinSymbol  string
+	Network     string
+	WithdrawMin types.Amount // Minimum withdrawal amount
+	WithdrawMax types.Amount // Maximum withdrawal amount; No maximum if 0
+	WithdrawFee types.Amount // Estimated network fee of a withdrawal
+	// WithdrawPrecision is the maximum number of decimals of the withdrawal amounts, 0 for
+	// whole units. Not checked if nil.
+	WithdrawPrecision *int32
+	// MemoRequired is whether the withdrawals require a memo/address tag, e.g. on XRP.
+	MemoRequired bool
+}
+
+type coinNetworkKey struct {
+	symbol  string
+	network string
+}
+
+// WithdrawalEstimate is the result of a valid withdrawal.
+type WithdrawalEstimate struct {
+	// CoinNetwork are the withdrawal rules of the coin on the network, nil if none is
+	// configured.
+	CoinNetwork *CoinNetwork
+	// Fee is the estimated network fee, the customized fee if set.
+	Fee types.Amount
+	// Total is the amount debited from the wallet, fee included.
+	Total types.Amount
+}
+
+// ValidateWithdrawal This method checks a withdrawal before sending it, and returns its estimated
+// network fee. It fails with a *ValidationError if:
+//   - neither or both of WithdrawalAddress and ToWalletIDStr are set,
+//   - the withdrawal address is invalid for the network, see ValidateAddress and
+//     Options.AddressValidators, or is not whitelisted according to
+//     Options.WhitelistChecker, if set,
+//   - ToWalletIDStr is not a positive integer; the wallet itself is not looked up,
+//   - the amount is out of the minimum and maximum, or has more decimals than the
+//     WithdrawPrecision of the network,
+//   - the MemoRequired rule of the network is set and the memo is empty,
+//   - request is nil.
+//
+// The coin and network rules are taken from Options.CoinNetworks, no request is sent
+// for them. Without rules for the coin and network, only the address and the whitelist
+// are checked, and the estimated fee is the customized fee, or zero.
+func (c *client) ValidateWithdrawal(ctx context.Context, request *types.WithdrawalRequest) (*WithdrawalEstimate, error) {
+	invalid := func(field, reason string) error {
+		return &ValidationError{Field: field, Reason: reason}
+	}
+
+	switch {
+	case request == nil:
+		return nil, invalid("request", "withdrawal request is nil")
+	case request.WithdrawalAddress == "" && request.ToWalletIDStr == "":
+		return nil, invalid("withdrawalAddress", "either withdrawalAddress or toWalletIdStr is required")
+	case request.WithdrawalAddress != "" && request.ToWalletIDStr != "":
+		return nil, invalid("withdrawalAddress", "only one of withdrawalAddress and toWalletIdStr can be set")
+	case request.CoinSymbol == "":
+		return nil, invalid("coinSymbol", "coin symbol is required")
+	case request.Network == "":
+		return nil, invalid("network", "network is required")
+	case request.Amount.Sign() <= 0:
+		return nil, invalid("amount", "amount must be positive")
+	case request.CustomizeFeeAmount != nil && request.CustomizeFeeAmount.Sign() < 0:
+		return nil, invalid("customizeFeeAmount", "fee must not be negative")
+	}
+
+	if request.ToWalletIDStr != "" {
+		// only a withdrawal address is checked against the whitelist
+		if id, err := strconv.ParseInt(request.ToWalletIDStr, 10, 64); err != nil || id <= 0 {
+			return nil, invalid("toWalletIdStr", "not a wallet id")
+		}
+	} else if err := validateAddress(c.addressValidators, request.Network, request.WithdrawalAddress); err != nil {
+		return nil, invalid("withdrawalAddress", err.Error())
+	}
+
+	var network *CoinNetwork
+	if rules, ok := c.coinNetworks[coinNetworkKey{request.CoinSymbol, request.Network}]; ok {
+		network = &rules
+		if request.Amount.Cmp(network.WithdrawMin) < 0 {
+			return nil, invalid("amount", fmt.Sprintf("amount is lower than the minimum %s", network.WithdrawMin))
+		}
+		if !network.WithdrawMax.IsZero() && request.Amount.Cmp(network.WithdrawMax) > 0 {
+			return nil, invalid("amount", fmt.Sprintf("amount is greater than the maximum %s", network.WithdrawMax))
+		}
+		if precision := network.WithdrawPrecision; precision != nil && request.Amount.ExceedsPrecision(*precision) {
+			return nil, invalid("amount", fmt.Sprintf("amount has more than %d decimals", *precision))
+		}
+		if network.MemoRequired && request.ToWalletIDStr == "" && request.Memo == "" {
+			return nil, invalid("memo", fmt.Sprintf("memo is required on %s", request.Network))
+		}
+	}
+
+	if request.WithdrawalAddress != "" && c.whitelistChecker != nil {
+		whitelisted, err := c.whitelistChecker.IsWhitelisted(ctx, request.WalletID, request.CoinSymbol, request.Network, request.WithdrawalAddress, request.Memo)
+		if err != nil {
+			return nil, err
+		}
+		if !whitelisted {
+			return nil, &ValidationError{Field: "withdrawalAddress", Reason: "address is not whitelisted", Err: ErrAddressNotWhitelisted}
+		}
+	}
+
+	var fee types.Amount
+	if network != nil {
+		fee = network.WithdrawFee
+	}
+	if request.CustomizeFeeAmount != nil {
+		fee = *request.CustomizeFeeAmount
+	}
+	return &WithdrawalEstimate{
+		CoinNetwork: network,
+		Fee:         fee,
+		Total:       request.Amount.Add(fee),
+	}, nil
+}
@@ -0,0 +1,283 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// whitelist returns a WhitelistChecker accepting the addresses of the network on any wallet.
+func whitelist(network string, addresses ...string) client.WhitelistChecker {
+	return client.WhitelistCheckerFunc(func(_ context.Context, _ int64, _, n, address, _ string) (bool, error) {
+		for _, a := range addresses {
+			if n == network && a == address {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func TestValidateWithdrawal(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	whitelisted := "0x52908400098527886E0F7030069857D2E4169EE7"
+	if err := srv.WhitelistAddress(walletID, "ETH", whitelisted); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetBalance(walletID, "ETH", "ETH", types.MustParseAmount("100")); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetBalance(walletID, "BTC", "BTC", types.MustParseAmount("100")); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetBalance(walletID, "USDT", "ETH", types.MustParseAmount("100")); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetBalance(walletID, "XRP", "XRP", types.MustParseAmount("100")); err != nil {
+		t.Fatal(err)
+	}
+	precision := int32(0)
+	rules := []client.CoinNetwork{
+		{
+			CoinSymbol:        "ETH",
+			Network:           "ETH",
+			WithdrawMin:       types.MustParseAmount("0.01"),
+			WithdrawFee:       types.MustParseAmount("0.001"),
+			WithdrawPrecision: &precision,
+		},
+		// no withdrawal precision
+		{
+			CoinSymbol:  "BTC",
+			Network:     "BTC",
+			WithdrawFee: types.MustParseAmount("0.0001"),
+		},
+		{
+			CoinSymbol:   "XRP",
+			Network:      "XRP",
+			WithdrawFee:  types.MustParseAmount("0.2"),
+			MemoRequired: true,
+		},
+	}
+	for _, network := range rules {
+		srv.SetCoinNetwork(network)
+	}
+	if err := srv.WhitelistAddress(walletID, "BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"); err != nil {
+		t.Fatal(err)
+	}
+	xrpAddress := "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+	if err := srv.WhitelistAddress(walletID, "XRP", xrpAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := client.WhitelistCheckerFunc(func(ctx context.Context, walletID int64, symbol, network, address, memo string) (bool, error) {
+		for _, w := range []client.WhitelistChecker{
+			whitelist("ETH", whitelisted),
+			whitelist("BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"),
+			whitelist("XRP", xrpAddress),
+		} {
+			if ok, _ := w.IsWhitelisted(ctx, walletID, symbol, network, address, memo); ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	c, err := srv.Client(client.Options{ValidateWithdrawals: true, CoinNetworks: rules, WhitelistChecker: checker})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(symbol, amount, address string) *types.WithdrawalRequest {
+		return &types.WithdrawalRequest{
+			WalletID:          walletID,
+			CoinSymbol:        symbol,
+			Network:           symbol,
+			Amount:            types.MustParseAmount(amount),
+			WithdrawalAddress: address,
+		}
+	}
+	tests := []struct {
+		name    string
+		request *types.WithdrawalRequest
+		field   string
+		err     error
+	}{
+		{"nil", nil, "request", nil},
+		{"whitelisted", request("ETH", "2", whitelisted), "", nil},
+		{"not whitelisted", request("ETH", "2", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"), "withdrawalAddress", client.ErrAddressNotWhitelisted},
+		{"invalid address", request("ETH", "2", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"), "withdrawalAddress", nil},
+		{"too many decimals", request("ETH", "1.5", whitelisted), "amount", nil},
+		{"precision not checked", request("BTC", "0.123456789", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"), "", nil},
+		{"memo required", request("XRP", "10", xrpAddress), "memo", nil},
+		{"memo", &types.WithdrawalRequest{WalletID: walletID, CoinSymbol: "XRP", Network: "XRP", Amount: types.MustParseAmount("10"), WithdrawalAddress: xrpAddress, Memo: "123456"}, "", nil},
+		{"lower than the minimum", request("ETH", "0.001", whitelisted), "amount", nil},
+		{"no rules", &types.WithdrawalRequest{WalletID: walletID, CoinSymbol: "USDT", Network: "ETH", Amount: types.MustParseAmount("0.0000001"), WithdrawalAddress: whitelisted}, "", nil},
+		{"no rules, not whitelisted", &types.WithdrawalRequest{WalletID: walletID, CoinSymbol: "USDT", Network: "ETH", Amount: types.MustParseAmount("1"), WithdrawalAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}, "withdrawalAddress", client.ErrAddressNotWhitelisted},
+	}
+	for _, tt := range tests {
+		_, err := c.Withdrawal(context.Background(), tt.request)
+		if tt.field == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		var validationErr *client.ValidationError
+		if !errors.As(err, &validationErr) || !errors.Is(err, client.ErrInvalidWithdrawal) || validationErr.Field != tt.field {
+			t.Errorf("%s: %v, want a validation error of %s", tt.name, err, tt.field)
+			continue
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// only the valid withdrawals are sent
+	sent := 0
+	for _, r := range srv.Requests() {
+		if r.Path == client.PathWithdrawal {
+			sent++
+		}
+	}
+	if sent != 4 {
+		t.Errorf("%d withdrawals sent, want 4", sent)
+	}
+
+	// the network rules and the whitelist come from the options, not from a request
+	for _, r := range srv.Requests() {
+		if r.Path != client.PathWithdrawal {
+			t.Errorf("unexpected request to %s", r.Path)
+		}
+	}
+
+	estimate, err := c.ValidateWithdrawal(context.Background(), request("ETH", "2", whitelisted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.CoinNetwork == nil || !estimate.Fee.Equal(types.MustParseAmount("0.001")) || !estimate.Total.Equal(types.MustParseAmount("2.001")) {
+		t.Errorf("estimate = %+v", estimate)
+	}
+	estimate, err = c.ValidateWithdrawal(context.Background(), &types.WithdrawalRequest{WalletID: walletID, CoinSymbol: "USDT", Network: "ETH", Amount: types.MustParseAmount("1"), WithdrawalAddress: whitelisted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.CoinNetwork != nil || !estimate.Fee.IsZero() || !estimate.Total.Equal(types.MustParseAmount("1")) {
+		t.Errorf("estimate without rules = %+v", estimate)
+	}
+}
+
+func TestWithdrawalNilRequest(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+
+	for _, validate := range []bool{false, true} {
+		c, err := srv.Client(client.Options{ValidateWithdrawals: validate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Withdrawal(context.Background(), nil)
+		var validationErr *client.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "request" {
+			t.Errorf("ValidateWithdrawals %v: %v, want a validation error of request", validate, err)
+		}
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("%d requests sent, want 0", len(srv.Requests()))
+	}
+}
+
+func TestAddressValidators(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+
+	errInvalid := errors.New("not a SOL address")
+	c, err := srv.Client(client.Options{WhitelistChecker: whitelist("ETH"), AddressValidators: map[string]client.AddressValidator{
+		"sol": func(address string) error {
+			if address != "So11111111111111111111111111111111111111112" {
+				return errInvalid
+			}
+			return nil
+		},
+		"ETH": nil,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := srv.Client(client.Options{WhitelistChecker: whitelist("ETH")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(network, address string) *types.WithdrawalRequest {
+		return &types.WithdrawalRequest{
+			WalletID:          walletID,
+			CoinSymbol:        network,
+			Network:           network,
+			Amount:            types.MustParseAmount("1"),
+			WithdrawalAddress: address,
+		}
+	}
+	// the addresses are valid but not whitelisted
+	badChecksum := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"
+	tests := []struct {
+		name    string
+		c       client.Client
+		request *types.WithdrawalRequest
+		reason  string
+	}{
+		{"custom validator", c, request("SOL", "not a SOL address"), errInvalid.Error()},
+		{"custom validator, valid", c, request("SOL", "So11111111111111111111111111111111111111112"), "address is not whitelisted"},
+		{"disabled validator", c, request("ETH", badChecksum), "address is not whitelisted"},
+		{"built-in validator", c, request("BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"), ""},
+		{"other client", other, request("ETH", badChecksum), ""},
+		{"other client, no validator", other, request("SOL", "not a SOL address"), "address is not whitelisted"},
+	}
+	for _, tt := range tests {
+		_, err := tt.c.ValidateWithdrawal(context.Background(), tt.request)
+		var validationErr *client.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "withdrawalAddress" {
+			t.Errorf("%s: %v, want a validation error of withdrawalAddress", tt.name, err)
+			continue
+		}
+		notWhitelisted := errors.Is(err, client.ErrAddressNotWhitelisted)
+		if tt.reason == "address is not whitelisted" != notWhitelisted || tt.reason != "" && validationErr.Reason != tt.reason {
+			t.Errorf("%s: %v, want %q", tt.name, err, tt.reason)
+		}
+	}
+}
+
+func TestValidateWithdrawalWithoutWhitelistChecker(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	if err := srv.SetBalance(walletID, "ETH", "ETH", types.MustParseAmount("100")); err != nil {
+		t.Fatal(err)
+	}
+	c, err := srv.Client(client.Options{ValidateWithdrawals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the whitelist is left to Ceffu
+	request := &types.WithdrawalRequest{
+		WalletID:          walletID,
+		CoinSymbol:        "ETH",
+		Network:           "ETH",
+		Amount:            types.MustParseAmount("1"),
+		WithdrawalAddress: "0x52908400098527886E0F7030069857D2E4169EE7",
+	}
+	if _, err := c.ValidateWithdrawal(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Withdrawal(context.Background(), request)
+	var requestErr *client.RequestError
+	if !errors.As(err, &requestErr) || !errors.Is(err, client.ErrAddressNotWhitelisted) {
+		t.Errorf("Withdrawal = %v, want a *RequestError matching ErrAddressNotWhitelisted", err)
+	}
+}
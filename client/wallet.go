@@ -3,17 +3,19 @@ package client
 import (
 	"context"
 	"encoding/json"
-	"strconv"
 	"time"
 
 	"github.com/mapprotocol/ceffu-go/types"
 )
 
 type Wallet interface {
-	Withdrawal(ctx context.Context, request *types.WithdrawalRequest) (*types.WithdrawalResponseData, error)
+	Withdrawal(ctx context.Context, request *types.WithdrawalRequest, opts ...CallOption) (*types.WithdrawalResponseData, error)
 	WithdrawalDetail(ctx context.Context, orderViewID string) (*types.Transaction, error)
-	TransferWithExchange(ctx context.Context, request *types.TransferWithExchangeRequest) (*types.Transfer, error)
+	WithdrawalDetailByRequestID(ctx context.Context, requestID string) (*types.Transaction, error)
+	TransferWithExchange(ctx context.Context, request *types.TransferWithExchangeRequest, opts ...CallOption) (*types.Transfer, error)
 	TransferDetailWithExchange(ctx context.Context, orderViewID string, walletID int64) (*types.TransferDetail, error)
+	TransferDetailByRequestID(ctx context.Context, requestID string, walletID int64) (*types.TransferDetail, error)
+	ValidateWithdrawal(ctx context.Context, request *types.WithdrawalRequest) (*WithdrawalEstimate, error)
 }
 
 // Withdrawal This method enables the withdrawal of funds from the specified wallet to an external address
@@ -26,24 +28,36 @@ type Wallet interface {
 // that is exact amount receiver will receive. Please use Get Withdrawal History v2
 // and Get Withdrawal Detail (v2) together with Withdrawal (v2).
 //
+// The request id is generated by the client unless provided with WithRequestID. The withdrawal
+// is checked with ValidateWithdrawal before being sent if Options.ValidateWithdrawals is set.
+//
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471332
-func (c *client) Withdrawal(ctx context.Context, request *types.WithdrawalRequest) (*types.WithdrawalResponseData, error) {
-	request.RequestID = c.RequestID.Generate()
+func (c *client) Withdrawal(ctx context.Context, request *types.WithdrawalRequest, opts ...CallOption) (*types.WithdrawalResponseData, error) {
+	if request == nil {
+		return nil, &ValidationError{Field: "request", Reason: "withdrawal request is nil"}
+	}
+	requestID, err := c.requestID(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.validateWithdrawals {
+		if _, err := c.ValidateWithdrawal(ctx, request); err != nil {
+			return nil, err
+		}
+	}
+	request.RequestID = requestID
 	request.Timestamp = time.Now().UnixMilli()
 
 	ret, err := c.Post(ctx, PathWithdrawal, request)
 	if err != nil {
-		return nil, NewRequestError(
-			PathWithdrawal,
-			WithError(err),
-		)
+		return nil, err
 	}
 	response := types.WithdrawalResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return nil, err
 	}
 	if response.Code != SuccessCode {
-		return nil, NewRequestError(
+		return nil, c.newRequestError(
 			PathWithdrawal,
 			WithCode(response.Code),
 			WithMessage(response.Message),
@@ -58,25 +72,34 @@ func (c *client) Withdrawal(ctx context.Context, request *types.WithdrawalReques
 //
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471329
 func (c *client) WithdrawalDetail(ctx context.Context, orderViewID string) (*types.Transaction, error) {
-	request := types.WithdrawalDetailRequest{
+	return c.withdrawalDetail(ctx, &types.WithdrawalDetailRequest{
 		OrderViewID: orderViewID,
-		RequestID:   strconv.FormatInt(c.RequestID.Generate(), 10),
 		Timestamp:   time.Now().UnixMilli(),
-	}
+	})
+}
+
+// WithdrawalDetailByRequestID This method allows to get withdrawal details by the client request id
+// of the withdrawal, see WithRequestID.
+//
+// reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471329
+func (c *client) WithdrawalDetailByRequestID(ctx context.Context, requestID string) (*types.Transaction, error) {
+	return c.withdrawalDetail(ctx, &types.WithdrawalDetailRequest{
+		RequestID: requestID,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
 
+func (c *client) withdrawalDetail(ctx context.Context, request *types.WithdrawalDetailRequest) (*types.Transaction, error) {
 	ret, err := c.Get(ctx, PathWithdrawalDetail, request)
 	if err != nil {
-		return nil, NewRequestError(
-			PathWithdrawalDetail,
-			WithError(err),
-		)
+		return nil, err
 	}
 	response := types.WithdrawalDetailResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return nil, err
 	}
 	if response.Code != SuccessCode {
-		return nil, NewRequestError(
+		return nil, c.newRequestError(
 			PathWithdrawalDetail,
 			WithCode(response.Code),
 			WithMessage(response.Message),
@@ -90,24 +113,27 @@ func (c *client) WithdrawalDetail(ctx context.Context, orderViewID string) (*typ
 //
 // Notes: Currently support from Ceffu to Exchange direction only.
 //
+// The request id is generated by the client unless provided with WithRequestID.
+//
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471337
-func (c *client) TransferWithExchange(ctx context.Context, request *types.TransferWithExchangeRequest) (*types.Transfer, error) {
-	request.RequestID = c.RequestID.Generate()
+func (c *client) TransferWithExchange(ctx context.Context, request *types.TransferWithExchangeRequest, opts ...CallOption) (*types.Transfer, error) {
+	requestID, err := c.requestID(opts)
+	if err != nil {
+		return nil, err
+	}
+	request.RequestID = requestID
 	request.Timestamp = time.Now().UnixMilli()
 
 	ret, err := c.Post(ctx, PathTransferWithExchange, request)
 	if err != nil {
-		return nil, NewRequestError(
-			PathTransferWithExchange,
-			WithError(err),
-		)
+		return nil, err
 	}
 	response := types.TransferWithExchangeResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return nil, err
 	}
 	if response.Code != SuccessCode {
-		return nil, NewRequestError(
+		return nil, c.newRequestError(
 			PathTransferWithExchange,
 			WithCode(response.Code),
 			WithMessage(response.Message),
@@ -122,26 +148,36 @@ func (c *client) TransferWithExchange(ctx context.Context, request *types.Transf
 //
 // reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471330
 func (c *client) TransferDetailWithExchange(ctx context.Context, orderViewID string, walletID int64) (*types.TransferDetail, error) {
-	request := types.TransferDetailWithExchangeRequest{
+	return c.transferDetailWithExchange(ctx, &types.TransferDetailWithExchangeRequest{
 		OrderViewID: orderViewID,
 		WalletID:    walletID,
-		RequestID:   strconv.FormatInt(c.RequestID.Generate(), 10),
 		Timestamp:   time.Now().UnixMilli(),
-	}
+	})
+}
+
+// TransferDetailByRequestID This method allows to get transfer details with Exchange by the client
+// request id of the transfer, see WithRequestID.
+//
+// reference: https://apidoc.ceffu.io/apidoc/shared-c9ece2c6-3ab4-4667-bb7d-c527fb3dbf78/api-3471330
+func (c *client) TransferDetailByRequestID(ctx context.Context, requestID string, walletID int64) (*types.TransferDetail, error) {
+	return c.transferDetailWithExchange(ctx, &types.TransferDetailWithExchangeRequest{
+		RequestID: requestID,
+		WalletID:  walletID,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
 
+func (c *client) transferDetailWithExchange(ctx context.Context, request *types.TransferDetailWithExchangeRequest) (*types.TransferDetail, error) {
 	ret, err := c.Post(ctx, PathTransferDetailWithExchange, request)
 	if err != nil {
-		return nil, NewRequestError(
-			PathTransferDetailWithExchange,
-			WithError(err),
-		)
+		return nil, err
 	}
 	response := types.TransferDetailWithExchangeResponse{}
 	if err := json.Unmarshal(ret, &response); err != nil {
 		return nil, err
 	}
 	if response.Code != SuccessCode {
-		return nil, NewRequestError(
+		return nil, c.newRequestError(
 			PathTransferDetailWithExchange,
 			WithCode(response.Code),
 			WithMessage(response.Message),
@@ -0,0 +1,118 @@
+// Package prometheus exports the client.Metrics of a Ceffu client to Prometheus.
+//
+//	metrics, err := prometheus.New(prom.DefaultRegisterer, prometheus.Options{})
+//	c, err := client.New(apiKey, apiKeySecret, client.Options{Metrics: metrics})
+//
+// The metrics are, with the default namespace:
+//   - ceffu_requests_total{operation, path, code}: operations by result code,
+//   - ceffu_request_duration_seconds{operation, path}: latency of the operations, retries included,
+//   - ceffu_retries_total{operation, path}: retried attempts,
+//   - ceffu_rate_limit_wait_seconds{path}: time attempts waited for the client-side rate limiter,
+//   - ceffu_sign_failures_total{path}: requests that could not be signed.
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+const DefaultNamespace = "ceffu"
+
+type Options struct {
+	// Namespace prefixes the metric names, DefaultNamespace if empty.
+	Namespace string
+	// ConstLabels are added to all the metrics, e.g. the account of the API key.
+	ConstLabels prom.Labels
+	// LatencyBuckets are the buckets of the request duration histogram, in seconds.
+	// Defaults to prom.DefBuckets.
+	LatencyBuckets []float64
+	// WaitBuckets are the buckets of the rate limit wait histogram, in seconds.
+	// Defaults to buckets from 1ms to about 16s.
+	WaitBuckets []float64
+}
+
+// Metrics implements client.Metrics with Prometheus collectors.
+type Metrics struct {
+	requests      *prom.CounterVec
+	latency       *prom.HistogramVec
+	retries       *prom.CounterVec
+	rateLimitWait *prom.HistogramVec
+	signFailures  *prom.CounterVec
+}
+
+var _ client.Metrics = (*Metrics)(nil)
+
+// New creates the metrics and registers them with the registerer.
+func New(registerer prom.Registerer, opts Options) (*Metrics, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+	if opts.LatencyBuckets == nil {
+		opts.LatencyBuckets = prom.DefBuckets
+	}
+	if opts.WaitBuckets == nil {
+		opts.WaitBuckets = prom.ExponentialBuckets(0.001, 2, 15)
+	}
+
+	m := &Metrics{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "requests_total",
+			Help:        "Ceffu API operations by result code: the API code, the HTTP status code or \"error\".",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"operation", "path", "code"}),
+		latency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Latency of the Ceffu API operations, retries included.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.LatencyBuckets,
+		}, []string{"operation", "path"}),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "retries_total",
+			Help:        "Retried attempts of Ceffu API operations.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"operation", "path"}),
+		rateLimitWait: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "rate_limit_wait_seconds",
+			Help:        "Time the attempts waited for the client-side rate limiter.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.WaitBuckets,
+		}, []string{"path"}),
+		signFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "sign_failures_total",
+			Help:        "Ceffu API requests that could not be signed.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"path"}),
+	}
+
+	for _, collector := range []prom.Collector{m.requests, m.latency, m.retries, m.rateLimitWait, m.signFailures} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Metrics) ObserveRequest(operation, path, code string, latency time.Duration) {
+	m.requests.WithLabelValues(operation, path, code).Inc()
+	m.latency.WithLabelValues(operation, path).Observe(latency.Seconds())
+}
+
+func (m *Metrics) IncRetry(operation, path string) {
+	m.retries.WithLabelValues(operation, path).Inc()
+}
+
+func (m *Metrics) ObserveRateLimitWait(path string, wait time.Duration) {
+	m.rateLimitWait.WithLabelValues(path).Observe(wait.Seconds())
+}
+
+func (m *Metrics) IncSignFailure(path string) {
+	m.signFailures.WithLabelValues(path).Inc()
+}
@@ -0,0 +1,124 @@
+package prometheus_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/metrics/prometheus"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+	if err := srv.WhitelistAddress(walletID, "ETH", address); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prom.NewRegistry()
+	metrics, err := prometheus.New(registry, prometheus.Options{ConstLabels: prom.Labels{"account": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prometheus.New(registry, prometheus.Options{}); err == nil {
+		t.Error("metrics registered twice")
+	}
+	c, err := srv.Client(client.Options{
+		Metrics: metrics,
+		RetryPolicy: &client.RetryPolicy{
+			MaxAttempts:          2,
+			InitialBackoff:       time.Millisecond,
+			RetryableStatusCodes: []int{503},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetDepositAddress(ctx, "ETH", "ETH", walletID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// rejected with an API code
+	if _, err := c.Withdrawal(ctx, &types.WithdrawalRequest{
+		WalletID:          walletID,
+		CoinSymbol:        "ETH",
+		Network:           "ETH",
+		Amount:            types.MustParseAmount("1"),
+		WithdrawalAddress: address,
+	}); err == nil {
+		t.Fatal("withdrawal without balance succeeded")
+	}
+	// rejected with an HTTP status, after a retry
+	srv.InjectFault(ceffutest.Fault{Path: client.PathWithdrawalDetail, StatusCode: 503, Times: 2})
+	if _, err := c.WithdrawalDetail(ctx, "1"); err == nil {
+		t.Fatal("HTTP fault not returned")
+	}
+	// no response, after a retry
+	srv.InjectFault(ceffutest.Fault{Path: client.PathWithdrawalDetail, CloseConnection: true})
+	if _, err := c.WithdrawalDetail(ctx, "1"); err == nil {
+		t.Fatal("connection fault not returned")
+	}
+	srv.ClearFaults()
+
+	expected := fmt.Sprintf(`
+# HELP ceffu_requests_total Ceffu API operations by result code: the API code, the HTTP status code or "error".
+# TYPE ceffu_requests_total counter
+ceffu_requests_total{account="test",code="%[1]s",operation="GetDepositAddress",path="%[2]s"} 2
+ceffu_requests_total{account="test",code="%[3]s",operation="Withdrawal",path="%[4]s"} 1
+ceffu_requests_total{account="test",code="503",operation="WithdrawalDetail",path="%[5]s"} 1
+ceffu_requests_total{account="test",code="error",operation="WithdrawalDetail",path="%[5]s"} 1
+# HELP ceffu_retries_total Retried attempts of Ceffu API operations.
+# TYPE ceffu_retries_total counter
+ceffu_retries_total{account="test",operation="WithdrawalDetail",path="%[5]s"} 2
+`, client.SuccessCode, client.PathGetDepositAddress, ceffutest.CodeInsufficientBalance, client.PathWithdrawal, client.PathWithdrawalDetail)
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "ceffu_requests_total", "ceffu_retries_total"); err != nil {
+		t.Error(err)
+	}
+
+	// one latency observation per operation, retries included
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "ceffu_request_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var operation string
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "operation" {
+					operation = label.GetValue()
+				}
+			}
+			h := m.GetHistogram()
+			if h.GetSampleSum() <= 0 {
+				t.Errorf("%s: latency sum %v", operation, h.GetSampleSum())
+			}
+			counts[operation] = h.GetSampleCount()
+		}
+	}
+	want := map[string]uint64{"GetDepositAddress": 2, "Withdrawal": 1, "WithdrawalDetail": 2}
+	if fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Errorf("latency observations = %v, want %v", counts, want)
+	}
+
+	// the rate limiter is not enabled
+	if n, err := testutil.GatherAndCount(registry, "ceffu_rate_limit_wait_seconds"); err != nil || n != 0 {
+		t.Errorf("%d rate limit wait series, %v, want 0", n, err)
+	}
+}
@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cursor is the persisted position of a DepositScanner.
+type Cursor struct {
+	// HighWaterMark is the end time (timestamp in milliseconds) of the last completed scan.
+	HighWaterMark int64 `json:"highWaterMark"`
+	// Deposits are the deposits already emitted that the next scan may return again,
+	// or that are not yet in a final status, by orderViewId.
+	Deposits map[string]*DepositState `json:"deposits"`
+}
+
+type DepositState struct {
+	Status    int64 `json:"status"`    // Last emitted status
+	ScanFrom  int64 `json:"scanFrom"`  // Start time of the scan the deposit was first seen by (timestamp in milliseconds)
+	FirstSeen int64 `json:"firstSeen"` // End time of the scan the deposit was first seen by (timestamp in milliseconds)
+	Expired   bool  `json:"expired"`   // Whether EventExpired was emitted
+}
+
+func (c *Cursor) clone() *Cursor {
+	clone := &Cursor{
+		HighWaterMark: c.HighWaterMark,
+		Deposits:      make(map[string]*DepositState, len(c.Deposits)),
+	}
+	for id, state := range c.Deposits {
+		s := *state
+		clone.Deposits[id] = &s
+	}
+	return clone
+}
+
+// CursorStore persists the cursor of a DepositScanner.
+type CursorStore interface {
+	// Load returns the saved cursor, or nil if none was saved yet.
+	Load(ctx context.Context) (*Cursor, error)
+	Save(ctx context.Context, cursor *Cursor) error
+}
+
+// MemoryCursorStore keeps the cursor in memory.
+type MemoryCursorStore struct {
+	mu     sync.Mutex
+	cursor *Cursor
+}
+
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{}
+}
+
+func (s *MemoryCursorStore) Load(_ context.Context) (*Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cursor == nil {
+		return nil, nil
+	}
+	return s.cursor.clone(), nil
+}
+
+func (s *MemoryCursorStore) Save(_ context.Context, cursor *Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursor = cursor.clone()
+	return nil
+}
+
+// FileCursorStore keeps the cursor in a JSON file. The file is replaced atomically on every save.
+type FileCursorStore struct {
+	path string
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+func (s *FileCursorStore) Load(_ context.Context) (*Cursor, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cursor := &Cursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (s *FileCursorStore) Save(_ context.Context, cursor *Cursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,276 @@
+// Package scanner implements a long-running DepositScanner emitting the deposits of a
+// wallet exactly once, built on the deposit history of the Ceffu API.
+//
+//	s := scanner.NewDepositScanner(c, primeWalletID, func(ctx context.Context, e scanner.Event) error {
+//		if e.Kind == scanner.EventConfirmed {
+//			return credit(ctx, e.Deposit)
+//		}
+//		return nil
+//	}, scanner.Options{Store: scanner.NewFileCursorStore("deposits.cursor")})
+//	err := s.Run(ctx)
+package scanner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+const (
+	DefaultInterval    = 30 * time.Second
+	DefaultOverlap     = 10 * time.Minute
+	DefaultMaxLookback = 7 * 24 * time.Hour
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// EventDeposit is emitted once when a deposit is first seen, whatever its status.
+	EventDeposit EventKind = iota + 1
+	// EventConfirmed is emitted once when a deposit reaches TransactionStatusConfirmed.
+	EventConfirmed
+	// EventExpired is emitted once when a deposit is still not final MaxLookback after it
+	// was first seen, with only the OrderViewID and the last emitted Status of the deposit.
+	// It is no longer scanned for, and its confirmation is only emitted if the deposit
+	// appears again in a later scan.
+	EventExpired
+)
+
+type Event struct {
+	Kind    EventKind
+	Deposit *types.Transaction
+}
+
+// Handler handles the events of the scanner. An event is emitted again by the next scan
+// if its handler returns an error.
+type Handler func(ctx context.Context, event Event) error
+
+type Options struct {
+	// CoinSymbol and Network filter the deposits, all coins and networks if empty.
+	CoinSymbol string
+	Network    string
+	// StartTime is the time (timestamp in milliseconds) the first scan starts from when
+	// the store has no cursor yet. Defaults to client.MaxHistoryWindow ago.
+	StartTime int64
+	// Interval is the time between two scans of Run, DefaultInterval if 0.
+	Interval time.Duration
+	// Overlap is how far before the end of the previous scan the next scan starts, to catch
+	// deposits that appear late in the history. DefaultOverlap if 0.
+	Overlap time.Duration
+	// MaxLookback is how long deposits that are not final yet keep being scanned for after
+	// they were first seen, DefaultMaxLookback if 0; see EventExpired. It does not limit
+	// how far back a scan resumes from: after a downtime, the next scan starts from the
+	// end of the previous one whatever its age.
+	MaxLookback time.Duration
+	// PageLimit is the page limit of the history queries, client.DefaultHistoryPageLimit if 0.
+	PageLimit int64
+	// Store persists the cursor, a MemoryCursorStore if nil.
+	Store CursorStore
+	// OnError is called by Run when a scan fails.
+	OnError func(err error)
+}
+
+// DepositScanner polls the deposit history of a wallet. For a prime wallet, it covers the
+// deposits of all its sub wallets.
+type DepositScanner struct {
+	client   client.SubWallet
+	walletID int64
+	handler  Handler
+	opts     Options
+
+	// now returns the current time, time.Now except in tests
+	now func() time.Time
+
+	mu     sync.Mutex
+	cursor *Cursor
+}
+
+func NewDepositScanner(c client.SubWallet, walletID int64, handler Handler, opts Options) *DepositScanner {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Overlap <= 0 {
+		opts.Overlap = DefaultOverlap
+	}
+	if opts.MaxLookback <= 0 {
+		opts.MaxLookback = DefaultMaxLookback
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryCursorStore()
+	}
+	return &DepositScanner{
+		client:   c,
+		walletID: walletID,
+		handler:  handler,
+		opts:     opts,
+		now:      time.Now,
+	}
+}
+
+// Run scans every Options.Interval until ctx is done, and returns ctx.Err().
+func (s *DepositScanner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Scan(ctx); err != nil && ctx.Err() == nil && s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan runs a single scan from the saved cursor up to now.
+//
+// A scan starts Options.Overlap before the end of the previous one, or from the start of
+// the scan that first saw the oldest deposit that is not final yet. The deposits are
+// tracked by the bounds of the scan that first saw them, not by their txTime, whose time
+// zone is not specified.
+func (s *DepositScanner) Scan(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UnixMilli()
+	from := cursor.HighWaterMark - s.opts.Overlap.Milliseconds()
+	if cursor.HighWaterMark == 0 {
+		from = s.opts.StartTime
+		if from == 0 {
+			from = now - client.MaxHistoryWindow.Milliseconds()
+		}
+	}
+	from = s.rescanFrom(cursor, from)
+
+	it := client.NewDepositHistoryIterator(s.client, s.walletID, s.opts.CoinSymbol, s.opts.Network, from, now, s.opts.PageLimit)
+	for {
+		tx, err := it.Next(ctx)
+		if err == client.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.handle(ctx, cursor, tx, from, now); err != nil {
+			return err
+		}
+	}
+
+	if err := s.expire(ctx, cursor, now-s.opts.MaxLookback.Milliseconds()); err != nil {
+		return err
+	}
+
+	cursor.HighWaterMark = now
+	// the deposits first seen before the start of the next scan are not returned again
+	next := s.rescanFrom(cursor, now-s.opts.Overlap.Milliseconds())
+	for id, state := range cursor.Deposits {
+		if (isFinal(state.Status) || state.Expired) && state.FirstSeen < next {
+			delete(cursor.Deposits, id)
+		}
+	}
+	return s.opts.Store.Save(ctx, cursor)
+}
+
+// rescanFrom returns the start of a scan from, moved back to the start of the scan that
+// first saw the oldest deposit that is not final yet.
+func (s *DepositScanner) rescanFrom(cursor *Cursor, from int64) int64 {
+	for _, state := range cursor.Deposits {
+		if !isFinal(state.Status) && !state.Expired && state.ScanFrom < from {
+			from = state.ScanFrom
+		}
+	}
+	return from
+}
+
+// expire emits EventExpired for the deposits first seen before oldest that are not final yet,
+// oldest first.
+func (s *DepositScanner) expire(ctx context.Context, cursor *Cursor, oldest int64) error {
+	var ids []string
+	for id, state := range cursor.Deposits {
+		if !isFinal(state.Status) && !state.Expired && state.FirstSeen < oldest {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := cursor.Deposits[ids[i]], cursor.Deposits[ids[j]]
+		if a.FirstSeen != b.FirstSeen {
+			return a.FirstSeen < b.FirstSeen
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		deposit := &types.Transaction{OrderViewID: id, Status: cursor.Deposits[id].Status}
+		if err := s.handler(ctx, Event{Kind: EventExpired, Deposit: deposit}); err != nil {
+			return err
+		}
+		cursor.Deposits[id].Expired = true
+		if err := s.opts.Store.Save(ctx, cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DepositScanner) load(ctx context.Context) (*Cursor, error) {
+	if s.cursor != nil {
+		return s.cursor, nil
+	}
+	cursor, err := s.opts.Store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil {
+		cursor = &Cursor{}
+	}
+	if cursor.Deposits == nil {
+		cursor.Deposits = make(map[string]*DepositState)
+	}
+	s.cursor = cursor
+	return cursor, nil
+}
+
+// handle emits the events of a deposit returned by the scan from scanFrom to scanTo that
+// were not emitted yet, and saves the cursor after each of them.
+func (s *DepositScanner) handle(ctx context.Context, cursor *Cursor, tx *types.Transaction, scanFrom, scanTo int64) error {
+	state, seen := cursor.Deposits[tx.OrderViewID]
+	if !seen {
+		if err := s.handler(ctx, Event{Kind: EventDeposit, Deposit: tx}); err != nil {
+			return err
+		}
+		state = &DepositState{Status: tx.Status, ScanFrom: scanFrom, FirstSeen: scanTo}
+		if tx.Status == types.TransactionStatusConfirmed {
+			// the confirmation is not emitted yet
+			state.Status = types.TransactionStatusSuccess
+		}
+		cursor.Deposits[tx.OrderViewID] = state
+		if err := s.opts.Store.Save(ctx, cursor); err != nil {
+			return err
+		}
+	}
+
+	if tx.Status == types.TransactionStatusConfirmed && state.Status != types.TransactionStatusConfirmed {
+		if err := s.handler(ctx, Event{Kind: EventConfirmed, Deposit: tx}); err != nil {
+			return err
+		}
+		state.Status = tx.Status
+		return s.opts.Store.Save(ctx, cursor)
+	}
+	state.Status = tx.Status
+	return nil
+}
+
+func isFinal(status int64) bool {
+	return status == types.TransactionStatusConfirmed || status == types.TransactionStatusFailed
+}
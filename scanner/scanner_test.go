@@ -0,0 +1,202 @@
+package scanner
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+type fixture struct {
+	srv      *ceffutest.Server
+	client   client.Client
+	walletID int64
+	// offset shifts the clock of the fake server, to create deposits in the past
+	offset int64
+	// clock shifts the clock of the scanners
+	clock  int64
+	events []Event
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{srv: ceffutest.NewServer()}
+	t.Cleanup(f.srv.Close)
+	f.srv.Now = func() time.Time {
+		return time.Now().Add(time.Duration(atomic.LoadInt64(&f.offset)))
+	}
+	c, err := f.srv.Client(client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.client = c
+	f.walletID = f.srv.CreatePrimeWallet("prime")
+	return f
+}
+
+// deposit creates a pending deposit ago.
+func (f *fixture) deposit(t *testing.T, ago time.Duration) string {
+	t.Helper()
+	atomic.StoreInt64(&f.offset, int64(-ago))
+	defer atomic.StoreInt64(&f.offset, 0)
+
+	orderViewID, err := f.srv.Deposit(f.walletID, "BTC", "BTC", types.MustParseAmount("1"), "bc1qsender")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orderViewID
+}
+
+func (f *fixture) confirm(t *testing.T, orderViewID string) {
+	t.Helper()
+	if err := f.srv.SetStatus(orderViewID, types.TransactionStatusConfirmed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fixture) scanner(opts Options) *DepositScanner {
+	s := NewDepositScanner(f.client, f.walletID, func(ctx context.Context, e Event) error {
+		f.events = append(f.events, e)
+		return nil
+	}, opts)
+	s.now = func() time.Time {
+		return time.Now().Add(time.Duration(atomic.LoadInt64(&f.clock)))
+	}
+	return s
+}
+
+// scan runs a scan and returns the events it emitted, as kind and orderViewId.
+func (f *fixture) scan(t *testing.T, s *DepositScanner) []string {
+	t.Helper()
+	f.events = nil
+	if err := s.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, e := range f.events {
+		kind := "deposit"
+		switch e.Kind {
+		case EventConfirmed:
+			kind = "confirmed"
+		case EventExpired:
+			kind = "expired"
+		}
+		events = append(events, kind+" "+e.Deposit.OrderViewID)
+	}
+	return events
+}
+
+func assertEvents(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("events = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestResumeAfterDowntime(t *testing.T) {
+	f := newFixture(t)
+	store := NewMemoryCursorStore()
+	// the last scan ended 10 days ago, longer ago than MaxLookback
+	cursor := &Cursor{HighWaterMark: time.Now().Add(-10 * 24 * time.Hour).UnixMilli()}
+	if err := store.Save(context.Background(), cursor); err != nil {
+		t.Fatal(err)
+	}
+	old := f.deposit(t, 9*24*time.Hour)
+	f.confirm(t, old)
+	recent := f.deposit(t, time.Hour)
+
+	s := f.scanner(Options{Store: store, MaxLookback: 7 * 24 * time.Hour})
+	assertEvents(t, f.scan(t, s), "deposit "+old, "confirmed "+old, "deposit "+recent)
+	assertEvents(t, f.scan(t, s))
+}
+
+func TestStartTime(t *testing.T) {
+	f := newFixture(t)
+	f.deposit(t, 25*24*time.Hour)
+	old := f.deposit(t, 15*24*time.Hour)
+
+	s := f.scanner(Options{StartTime: time.Now().Add(-20 * 24 * time.Hour).UnixMilli()})
+	assertEvents(t, f.scan(t, s), "deposit "+old)
+}
+
+func TestUnconfirmedDeposits(t *testing.T) {
+	f := newFixture(t)
+	pending := f.deposit(t, 2*time.Hour)
+	expired := f.deposit(t, 3*time.Hour)
+
+	s := f.scanner(Options{Overlap: time.Minute, MaxLookback: 150 * time.Minute})
+	assertEvents(t, f.scan(t, s), "deposit "+pending, "deposit "+expired)
+	assertEvents(t, f.scan(t, s))
+
+	// deposits confirmed before the overlap are scanned again, whatever their age
+	f.confirm(t, pending)
+	assertEvents(t, f.scan(t, s), "confirmed "+pending)
+	assertEvents(t, f.scan(t, s))
+
+	// deposits not final MaxLookback after they were first seen expire
+	atomic.StoreInt64(&f.clock, int64(149*time.Minute))
+	assertEvents(t, f.scan(t, s))
+	atomic.StoreInt64(&f.clock, int64(151*time.Minute))
+	assertEvents(t, f.scan(t, s), "expired "+expired)
+	if _, ok := s.cursor.Deposits[expired]; ok {
+		t.Error("expired deposit kept in the cursor")
+	}
+	f.confirm(t, expired)
+	assertEvents(t, f.scan(t, s))
+}
+
+func TestExpiredDepositConfirmed(t *testing.T) {
+	f := newFixture(t)
+	s := f.scanner(Options{Overlap: 5 * time.Minute, MaxLookback: time.Hour})
+	first := f.deposit(t, time.Minute)
+	assertEvents(t, f.scan(t, s), "deposit "+first)
+
+	atomic.StoreInt64(&f.clock, int64(30*time.Minute))
+	second := f.deposit(t, -30*time.Minute)
+	assertEvents(t, f.scan(t, s), "deposit "+second)
+
+	// second keeps the window of first scanned, so the confirmation of first is emitted
+	atomic.StoreInt64(&f.clock, int64(61*time.Minute))
+	assertEvents(t, f.scan(t, s), "expired "+first)
+	f.confirm(t, first)
+	atomic.StoreInt64(&f.clock, int64(62*time.Minute))
+	assertEvents(t, f.scan(t, s), "confirmed "+first)
+
+	atomic.StoreInt64(&f.clock, int64(91*time.Minute))
+	assertEvents(t, f.scan(t, s), "expired "+second)
+	f.confirm(t, second)
+	atomic.StoreInt64(&f.clock, int64(92*time.Minute))
+	assertEvents(t, f.scan(t, s))
+	if len(s.cursor.Deposits) != 0 {
+		t.Errorf("%d deposits kept in the cursor", len(s.cursor.Deposits))
+	}
+}
+
+func TestTxTimeLocation(t *testing.T) {
+	for _, offset := range []int{-10, 8} {
+		f := newFixture(t)
+		f.srv.SetLocation(time.FixedZone("", offset*3600))
+		s := f.scanner(Options{Overlap: 10 * time.Minute, MaxLookback: 2 * time.Hour})
+
+		pending := f.deposit(t, 30*time.Minute)
+		recent := f.deposit(t, 30*time.Second)
+		f.confirm(t, recent)
+		assertEvents(t, f.scan(t, s), "deposit "+pending, "deposit "+recent, "confirmed "+recent)
+		// recent is still inside the overlap window and not emitted again
+		assertEvents(t, f.scan(t, s))
+
+		// pending is older than the overlap window and scanned again until final
+		f.confirm(t, pending)
+		assertEvents(t, f.scan(t, s), "confirmed "+pending)
+		assertEvents(t, f.scan(t, s))
+	}
+}
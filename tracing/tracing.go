@@ -0,0 +1,148 @@
+// Package tracing traces the operations of a Ceffu client with OpenTelemetry.
+//
+//	c, err := client.New(apiKey, apiKeySecret, client.Options{
+//		Middlewares: []client.Middleware{tracing.Middleware(tracing.Options{})},
+//	})
+//
+// Every operation starts a client span named after it, e.g. "ceffu.Withdrawal", as a
+// child of the span of the caller's context. The context of the span is passed on to
+// the HTTP client, so that an instrumented transport (e.g. otelhttp) propagates it.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+// InstrumentationName is the name of the tracer.
+const InstrumentationName = "github.com/mapprotocol/ceffu-go/tracing"
+
+// SpanPrefix prefixes the operation names in span names.
+const SpanPrefix = "ceffu."
+
+// Attribute keys of the spans.
+const (
+	AttributeOperation   = attribute.Key("ceffu.operation")
+	AttributePath        = attribute.Key("ceffu.path")
+	AttributeWalletID    = attribute.Key("ceffu.wallet_id")
+	AttributeCoinSymbol  = attribute.Key("ceffu.coin_symbol")
+	AttributeNetwork     = attribute.Key("ceffu.network")
+	AttributeRequestID   = attribute.Key("ceffu.request_id")
+	AttributeOrderViewID = attribute.Key("ceffu.order_view_id")
+	AttributeCode        = attribute.Key("ceffu.code")
+	AttributeMessage     = attribute.Key("ceffu.message")
+	AttributeHTTPMethod  = attribute.Key("http.method")
+	AttributeHTTPStatus  = attribute.Key("http.status_code")
+)
+
+// requestAttributes are the request fields recorded on the spans, by json name.
+var requestAttributes = []struct {
+	field string
+	key   attribute.Key
+}{
+	{"walletId", AttributeWalletID},
+	{"parentWalletId", AttributeWalletID},
+	{"coinSymbol", AttributeCoinSymbol},
+	{"network", AttributeNetwork},
+	{"requestId", AttributeRequestID},
+	{"orderViewId", AttributeOrderViewID},
+}
+
+type Options struct {
+	// TracerProvider creates the tracer, the global provider if nil.
+	TracerProvider trace.TracerProvider
+}
+
+// Middleware returns the client.Middleware tracing the operations. It should be the
+// first middleware, so that the span covers the others.
+func Middleware(opts Options) client.Middleware {
+	provider := opts.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(InstrumentationName)
+
+	return func(next client.RoundTripper) client.RoundTripper {
+		return client.RoundTripperFunc(func(ctx context.Context, op *client.Operation) (*client.Response, error) {
+			attrs := []attribute.KeyValue{
+				AttributeOperation.String(op.Name),
+				AttributePath.String(op.Path),
+				AttributeHTTPMethod.String(op.Method),
+			}
+			for _, a := range requestAttributes {
+				if value := op.Field(a.field); value != "" {
+					attrs = append(attrs, a.key.String(value))
+				}
+			}
+			ctx, span := tracer.Start(ctx, SpanPrefix+op.Name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			resp, err := next.RoundTrip(ctx, op)
+			if resp != nil {
+				span.SetAttributes(AttributeHTTPStatus.Int(resp.StatusCode))
+				if resp.Code != "" {
+					span.SetAttributes(AttributeCode.String(resp.Code))
+				}
+			}
+			switch {
+			case err != nil:
+				span.RecordError(err, trace.WithAttributes(errorAttributes(err)...))
+				span.SetStatus(codes.Error, errorStatus(err))
+			case resp.Code != client.SuccessCode:
+				// rejected by Ceffu, returned as a *client.RequestError by the Client methods
+				span.AddEvent("ceffu.error", trace.WithAttributes(
+					AttributeCode.String(resp.Code),
+					AttributeMessage.String(resp.Message),
+				))
+				span.SetStatus(codes.Error, resp.Code+": "+resp.Message)
+			default:
+				span.SetStatus(codes.Ok, "")
+			}
+			return resp, err
+		})
+	}
+}
+
+// errorAttributes returns the details of a *client.RequestError.
+func errorAttributes(err error) []attribute.KeyValue {
+	var requestErr *client.RequestError
+	if !errors.As(err, &requestErr) {
+		return nil
+	}
+	var attrs []attribute.KeyValue
+	if requestErr.StatusCode != 0 {
+		attrs = append(attrs, AttributeHTTPStatus.Int(requestErr.StatusCode))
+	}
+	if requestErr.Code != "" {
+		attrs = append(attrs, AttributeCode.String(requestErr.Code))
+	}
+	if requestErr.Message != "" {
+		attrs = append(attrs, AttributeMessage.String(requestErr.Message))
+	}
+	if category := requestErr.Category(); category != nil {
+		attrs = append(attrs, attribute.String("ceffu.category", category.Error()))
+	}
+	if requestErr.Err != nil {
+		attrs = append(attrs, attribute.String("ceffu.cause", requestErr.Err.Error()))
+	}
+	return attrs
+}
+
+func errorStatus(err error) string {
+	var requestErr *client.RequestError
+	if errors.As(err, &requestErr) && requestErr.StatusCode != 0 {
+		return "HTTP " + strconv.Itoa(requestErr.StatusCode)
+	}
+	return err.Error()
+}
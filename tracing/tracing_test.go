@@ -0,0 +1,187 @@
+package tracing_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/tracing"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// spanAttributes returns the attributes of a span, or of one of its events, by key.
+func spanAttributes(kvs []attribute.KeyValue) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string, len(kvs))
+	for _, kv := range kvs {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+func TestMiddleware(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	walletID := srv.CreatePrimeWallet("prime")
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+	if err := srv.WhitelistAddress(walletID, "ETH", address); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	apiKey, apiKeySecret, err := srv.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(apiKey, apiKeySecret, client.Options{
+		Domain:      srv.URL,
+		HttpClient:  srv.Server.Client(),
+		ErrorCodes:  ceffutest.ErrorCodes(),
+		Middlewares: []client.Middleware{tracing.Middleware(tracing.Options{TracerProvider: provider})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	if _, err := c.GetDepositAddress(ctx, "ETH", "ETH", walletID); err != nil {
+		t.Fatal(err)
+	}
+	// rejected by Ceffu with an API code
+	_, err = c.Withdrawal(ctx, &types.WithdrawalRequest{
+		WalletID:          walletID,
+		CoinSymbol:        "ETH",
+		Network:           "ETH",
+		Amount:            types.MustParseAmount("1"),
+		WithdrawalAddress: address,
+	}, client.WithRequestID("req-1"))
+	if err == nil {
+		t.Fatal("withdrawal without balance succeeded")
+	}
+	// rejected with an HTTP status
+	srv.InjectFault(ceffutest.Fault{Path: client.PathWithdrawalDetail, StatusCode: 503, Times: 1})
+	if _, err := c.WithdrawalDetail(ctx, "1"); err == nil {
+		t.Fatal("fault not returned")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("%d spans, want 4", len(spans))
+	}
+	tests := []struct {
+		name   string
+		attrs  map[attribute.Key]string
+		status codes.Code
+		event  string
+	}{
+		{
+			"ceffu.GetDepositAddress",
+			map[attribute.Key]string{
+				tracing.AttributeOperation:  "GetDepositAddress",
+				tracing.AttributePath:       client.PathGetDepositAddress,
+				tracing.AttributeHTTPMethod: "GET",
+				tracing.AttributeHTTPStatus: "200",
+				tracing.AttributeCode:       client.SuccessCode,
+				tracing.AttributeWalletID:   strconv.FormatInt(walletID, 10),
+				tracing.AttributeCoinSymbol: "ETH",
+				tracing.AttributeNetwork:    "ETH",
+			},
+			codes.Ok, "",
+		},
+		{
+			"ceffu.Withdrawal",
+			map[attribute.Key]string{
+				tracing.AttributeOperation:  "Withdrawal",
+				tracing.AttributePath:       client.PathWithdrawal,
+				tracing.AttributeHTTPMethod: "POST",
+				tracing.AttributeHTTPStatus: "200",
+				tracing.AttributeCode:       ceffutest.CodeInsufficientBalance,
+				tracing.AttributeRequestID:  "req-1",
+				tracing.AttributeCoinSymbol: "ETH",
+			},
+			codes.Error, "ceffu.error",
+		},
+		{
+			"ceffu.WithdrawalDetail",
+			map[attribute.Key]string{
+				tracing.AttributeOperation:   "WithdrawalDetail",
+				tracing.AttributePath:        client.PathWithdrawalDetail,
+				tracing.AttributeHTTPStatus:  "503",
+				tracing.AttributeOrderViewID: "1",
+			},
+			codes.Error, "exception",
+		},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d: name %q, want %q", i, span.Name(), tt.name)
+			continue
+		}
+		if span.SpanKind() != trace.SpanKindClient || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: kind %v, parent %v", tt.name, span.SpanKind(), span.Parent().SpanID())
+		}
+		attrs := spanAttributes(span.Attributes())
+		for key, want := range tt.attrs {
+			if attrs[key] != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, key, attrs[key], want)
+			}
+		}
+		if span.Status().Code != tt.status {
+			t.Errorf("%s: status %v, want %v", tt.name, span.Status(), tt.status)
+		}
+		var events []string
+		for _, e := range span.Events() {
+			events = append(events, e.Name)
+		}
+		if tt.event == "" && len(events) != 0 || tt.event != "" && (len(events) != 1 || events[0] != tt.event) {
+			t.Errorf("%s: events %q, want %q", tt.name, events, tt.event)
+		}
+	}
+
+	withdrawal := spanAttributes(spans[1].Events()[0].Attributes)
+	if withdrawal[tracing.AttributeCode] != ceffutest.CodeInsufficientBalance || withdrawal[tracing.AttributeMessage] == "" {
+		t.Errorf("ceffu.error event attributes = %v", withdrawal)
+	}
+	detail := spanAttributes(spans[2].Events()[0].Attributes)
+	if detail[tracing.AttributeHTTPStatus] != "503" || detail["ceffu.category"] != client.ErrServer.Error() {
+		t.Errorf("exception event attributes = %v", detail)
+	}
+	if status := spans[2].Status().Description; status != "HTTP 503" {
+		t.Errorf("status description %q, want HTTP 503", status)
+	}
+
+	// neither the credentials, the signatures nor the withdrawal address are recorded
+	secrets := []string{apiKey, apiKeySecret, address, strings.ToLower(address)}
+	for _, span := range spans {
+		values := []string{span.Name(), span.Status().Description}
+		for _, kv := range span.Attributes() {
+			values = append(values, kv.Value.Emit())
+		}
+		for _, e := range span.Events() {
+			for _, kv := range e.Attributes {
+				values = append(values, kv.Value.Emit())
+			}
+		}
+		for _, value := range values {
+			for _, secret := range secrets {
+				if strings.Contains(value, secret) {
+					t.Errorf("%s records %q", span.Name(), value)
+				}
+			}
+			if strings.Contains(value, "signature") {
+				t.Errorf("%s records a signature: %q", span.Name(), value)
+			}
+		}
+	}
+}
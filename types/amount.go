@@ -0,0 +1,268 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is an exact decimal number used for every amount sent to or received from Ceffu.
+// Its value is unscaled * 10^-scale. The zero value is 0.
+//
+// Amount is marshalled to JSON as a string, e.g. "0.000000000000000001", and can be
+// unmarshalled from either a JSON string or a JSON number.
+type Amount struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+var bigTen = big.NewInt(10)
+
+// MaxAmountScale bounds the exponents and scales accepted by ParseAmount, so that
+// amounts such as "1e999999999" decoded from a response do not allocate huge numbers.
+const MaxAmountScale = 1000
+
+// NewAmount returns the amount unscaled * 10^-scale, e.g. NewAmount(15, 1) is 1.5.
+func NewAmount(unscaled int64, scale int32) Amount {
+	return newAmount(big.NewInt(unscaled), scale)
+}
+
+func newAmount(unscaled *big.Int, scale int32) Amount {
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Amount{unscaled: unscaled, scale: scale}
+}
+
+// ParseAmount parses a decimal string such as "1", "-0.25" or "1.5e-8". The exponent
+// and the resulting scale must be within ±MaxAmountScale.
+func ParseAmount(s string) (Amount, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i != -1 {
+		e, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil || e < -MaxAmountScale || e > MaxAmountScale {
+			return Amount{}, fmt.Errorf("invalid amount %q", s)
+		}
+		exp = e
+		str = str[:i]
+	}
+
+	digits := str
+	var scale int64
+	if i := strings.IndexByte(str, '.'); i != -1 {
+		digits = str[:i] + str[i+1:]
+		scale = int64(len(str) - i - 1)
+	}
+	sign := ""
+	if digits != "" && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if scale-exp < -MaxAmountScale || scale-exp > MaxAmountScale {
+		return Amount{}, fmt.Errorf("amount %q is out of range", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	return newAmount(unscaled, int32(scale-exp)), nil
+}
+
+// MustParseAmount is like ParseAmount but panics if s cannot be parsed.
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a Amount) int() *big.Int {
+	if a.unscaled == nil {
+		return new(big.Int)
+	}
+	return a.unscaled
+}
+
+// Scale returns the number of digits after the decimal point.
+func (a Amount) Scale() int32 {
+	return a.scale
+}
+
+// rescale returns the unscaled value of a at the given scale, which must not be lower than a.scale.
+func (a Amount) rescale(scale int32) *big.Int {
+	if scale == a.scale {
+		return a.int()
+	}
+	return new(big.Int).Mul(a.int(), pow10(scale-a.scale))
+}
+
+func align(a, b Amount) (*big.Int, *big.Int, int32) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+// Add returns a + b.
+func (a Amount) Add(b Amount) Amount {
+	x, y, scale := align(a, b)
+	return Amount{unscaled: new(big.Int).Add(x, y), scale: scale}
+}
+
+// Sub returns a - b.
+func (a Amount) Sub(b Amount) Amount {
+	x, y, scale := align(a, b)
+	return Amount{unscaled: new(big.Int).Sub(x, y), scale: scale}
+}
+
+// Mul returns a * b.
+func (a Amount) Mul(b Amount) Amount {
+	return Amount{unscaled: new(big.Int).Mul(a.int(), b.int()), scale: a.scale + b.scale}
+}
+
+// Neg returns -a.
+func (a Amount) Neg() Amount {
+	return Amount{unscaled: new(big.Int).Neg(a.int()), scale: a.scale}
+}
+
+// Abs returns |a|.
+func (a Amount) Abs() Amount {
+	return Amount{unscaled: new(big.Int).Abs(a.int()), scale: a.scale}
+}
+
+// Cmp compares a and b and returns -1 if a < b, 0 if a == b and +1 if a > b.
+func (a Amount) Cmp(b Amount) int {
+	x, y, _ := align(a, b)
+	return x.Cmp(y)
+}
+
+// Equal reports whether a and b represent the same value, e.g. 1.5 and 1.50.
+func (a Amount) Equal(b Amount) bool {
+	return a.Cmp(b) == 0
+}
+
+// Sign returns -1 if a < 0, 0 if a == 0 and +1 if a > 0.
+func (a Amount) Sign() int {
+	return a.int().Sign()
+}
+
+// IsZero reports whether a is 0.
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Truncate returns a with at most scale digits after the decimal point, discarding the rest.
+func (a Amount) Truncate(scale int32) Amount {
+	if scale < 0 {
+		scale = 0
+	}
+	if a.scale <= scale {
+		return a
+	}
+	return Amount{unscaled: new(big.Int).Quo(a.int(), pow10(a.scale-scale)), scale: scale}
+}
+
+// Round returns a rounded half away from zero to at most scale digits after the decimal point.
+func (a Amount) Round(scale int32) Amount {
+	if scale < 0 {
+		scale = 0
+	}
+	if a.scale <= scale {
+		return a
+	}
+	q, r := new(big.Int).QuoRem(a.int(), pow10(a.scale-scale), new(big.Int))
+	r.Abs(r).Mul(r, big.NewInt(2))
+	if r.Cmp(pow10(a.scale-scale)) >= 0 {
+		q.Add(q, big.NewInt(int64(a.Sign())))
+	}
+	return Amount{unscaled: q, scale: scale}
+}
+
+// String returns the decimal representation of a without trailing zeros, e.g. "1.5".
+func (a Amount) String() string {
+	s := a.int().String()
+	if a.scale == 0 {
+		return s
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if pad := int(a.scale) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	i := len(s) - int(a.scale)
+	s = strings.TrimRight(s[:i]+"."+s[i:], "0")
+	s = strings.TrimSuffix(s, ".")
+	if neg && s != "0" {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a JSON string or number,
+// and decodes null and "" as 0.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*a = Amount{}
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*a = Amount{}
+			return nil
+		}
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// NumberAmount is an Amount marshalled to JSON as a number, e.g. 0.000000000000000001,
+// for the request fields the API expects as JSON numbers. It is exact, unlike float64.
+type NumberAmount struct {
+	Amount
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a NumberAmount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// ExceedsPrecision reports whether a has more significant digits after the decimal point
+// than precision, e.g. the withdrawal precision of a coin on a network. Trailing zeros
+// are not significant.
+func (a Amount) ExceedsPrecision(precision int32) bool {
+	return !a.Truncate(precision).Equal(a)
+}
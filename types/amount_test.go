@@ -0,0 +1,197 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		scale int32
+		err   bool
+	}{
+		{in: "1", want: "1", scale: 0},
+		{in: "-0.25", want: "-0.25", scale: 2},
+		{in: "+3.10", want: "3.1", scale: 2},
+		{in: " 7 ", want: "7", scale: 0},
+		{in: "0.000000000000000001", want: "0.000000000000000001", scale: 18},
+		{in: "123456789012345678901234567890.123456789012345678", want: "123456789012345678901234567890.123456789012345678", scale: 18},
+		{in: "1.5e-8", want: "0.000000015", scale: 9},
+		{in: "1.5E3", want: "1500", scale: 0},
+		{in: "1e1000", want: "1" + zeros(1000), scale: 0},
+		{in: "1e-1000", want: "0." + zeros(999) + "1", scale: 1000},
+		{in: "", err: true},
+		{in: "-", err: true},
+		{in: "1.2.3", err: true},
+		{in: "abc", err: true},
+		{in: "1e", err: true},
+		{in: "1e1001", err: true},
+		{in: "1e-1001", err: true},
+		{in: "1e999999999", err: true},
+		{in: "1e99999999999", err: true},
+		{in: "0.1e-1000", err: true},
+		{in: "0." + zeros(1000) + "1", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseAmount(%q) = %s, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAmount(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want || got.Scale() != tt.scale {
+			t.Errorf("ParseAmount(%q) = %s (scale %d), want %s (scale %d)", tt.in, got, got.Scale(), tt.want, tt.scale)
+		}
+	}
+}
+
+func zeros(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = '0'
+	}
+	return string(b)
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a, b := MustParseAmount("1.000000000000000001"), MustParseAmount("0.5")
+	tests := []struct {
+		name string
+		got  Amount
+		want string
+	}{
+		{"add", a.Add(b), "1.500000000000000001"},
+		{"sub", b.Sub(a), "-0.500000000000000001"},
+		{"mul", b.Mul(MustParseAmount("0.2")), "0.1"},
+		{"neg", a.Neg(), "-1.000000000000000001"},
+		{"abs", a.Neg().Abs(), "1.000000000000000001"},
+		{"zero add", Amount{}.Add(b), "0.5"},
+		{"float pitfall", MustParseAmount("0.1").Add(MustParseAmount("0.2")), "0.3"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Error("Cmp does not order 1.000000000000000001 and 0.5")
+	}
+	if !MustParseAmount("1.5").Equal(MustParseAmount("1.50")) {
+		t.Error("1.5 is not equal to 1.50")
+	}
+	if zero := (Amount{}); !zero.IsZero() || zero.Sign() != 0 || b.Neg().Sign() != -1 {
+		t.Error("Sign or IsZero is wrong")
+	}
+}
+
+func TestAmountRounding(t *testing.T) {
+	tests := []struct {
+		in       string
+		scale    int32
+		truncate string
+		round    string
+	}{
+		{"1.2345", 2, "1.23", "1.23"},
+		{"1.235", 2, "1.23", "1.24"},
+		{"-1.235", 2, "-1.23", "-1.24"},
+		{"-1.234", 2, "-1.23", "-1.23"},
+		{"0.5", 0, "0", "1"},
+		{"-0.5", 0, "0", "-1"},
+		{"0.4999", 0, "0", "0"},
+		{"1.5", 6, "1.5", "1.5"},
+		{"1.5", -1, "1", "2"},
+	}
+	for _, tt := range tests {
+		a := MustParseAmount(tt.in)
+		if got := a.Truncate(tt.scale).String(); got != tt.truncate {
+			t.Errorf("%s.Truncate(%d) = %s, want %s", tt.in, tt.scale, got, tt.truncate)
+		}
+		if got := a.Round(tt.scale).String(); got != tt.round {
+			t.Errorf("%s.Round(%d) = %s, want %s", tt.in, tt.scale, got, tt.round)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"1.000000000000000001"`, `"1.000000000000000001"`},
+		{`1.000000000000000001`, `"1.000000000000000001"`},
+		{`"-0.10"`, `"-0.1"`},
+		{`1e-18`, `"0.000000000000000001"`},
+		{`""`, `"0"`},
+		{`null`, `"0"`},
+	}
+	for _, tt := range tests {
+		var a Amount
+		if err := json.Unmarshal([]byte(tt.in), &a); err != nil {
+			t.Errorf("Unmarshal(%s) error: %v", tt.in, err)
+			continue
+		}
+		got, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("round trip of %s = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"1e999999999"`, `1e999999999`, `"x"`, `true`} {
+		var a Amount
+		if err := json.Unmarshal([]byte(in), &a); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want error", in, a)
+		}
+	}
+}
+
+func TestNumberAmountJSON(t *testing.T) {
+	request := TransferRequest{Amount: NumberAmount{MustParseAmount("0.000000000000000001")}}
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"coinSymbol":"","amount":0.000000000000000001,"fromWalletId":0,"toWalletId":0,"requestId":"","timestamp":0}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	var decoded TransferRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Amount.Equal(request.Amount.Amount) {
+		t.Errorf("round trip = %s, want %s", decoded.Amount, request.Amount)
+	}
+}
+
+func TestExceedsPrecision(t *testing.T) {
+	tests := []struct {
+		amount    string
+		precision int32
+		exceeds   bool
+	}{
+		{"0.00000001", 8, false},
+		{"0.000000001", 8, true},
+		{"1.50000000000", 1, false},
+		{"1.5", 0, true},
+		{"100", 0, false},
+		{"-0.123", 2, true},
+		{"0", 0, false},
+	}
+	for _, tt := range tests {
+		if got := MustParseAmount(tt.amount).ExceedsPrecision(tt.precision); got != tt.exceeds {
+			t.Errorf("%s.ExceedsPrecision(%d) = %v, want %v", tt.amount, tt.precision, got, tt.exceeds)
+		}
+	}
+}
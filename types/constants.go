@@ -16,6 +16,15 @@ const (
 	TransferDirectionSubWalletToSubWallet    = 30
 )
 
+const (
+	ExchangeCodeBinance = 10
+)
+
+const (
+	ExchangeTransferDirectionCustodyToExchange = 10
+	ExchangeTransferDirectionExchangeToCustody = 20
+)
+
 const (
 	TransactionStatusPending    = 10
 	TransactionStatusProcessing = 20
@@ -4,7 +4,7 @@ type CreatSubWalletRequest struct {
 	ParentWalletID string `json:"parentWalletId"`           // parent wallet id
 	WalletName     string `json:"walletName,omitempty"`     // Sub Wallet name (Max 20 characters)
 	AutoCollection int64  `json:"autoCollection,omitempty"` // Enable auto sweeping to parent wallet; ; 0: Not enable (Default Value), Suitable for API user who required Custody to maintain; asset ledger of each subaccount; ; 1: Enable, Suitable for API user who will maintain asset ledger of each subaccount at; their end.
-	RequestID      int64  `json:"requestId"`                // Request identity
+	RequestID      string `json:"requestId"`                // Request identity
 	Timestamp      int64  `json:"timestamp"`                // Current Timestamp
 }
 
@@ -27,27 +27,29 @@ type GetDepositHistoryRequest struct {
 }
 
 type TransferRequest struct {
-	CoinSymbol   string  `json:"coinSymbol"`   // Coin symbol
-	Amount       float64 `json:"amount"`       // Transfer amount
-	FromWalletID int64   `json:"fromWalletId"` // From wallet ID
-	ToWalletID   int64   `json:"toWalletId"`   // To wallet ID
-	RequestID    int64   `json:"requestId"`    // Client request identifier, Client provided Unique Identifier. (Max 70 characters)
-	Timestamp    int64   `json:"timestamp"`    // Current timestamp in millisecond
+	CoinSymbol   string       `json:"coinSymbol"`   // Coin symbol
+	Amount       NumberAmount `json:"amount"`       // Transfer amount
+	FromWalletID int64        `json:"fromWalletId"` // From wallet ID
+	ToWalletID   int64        `json:"toWalletId"`   // To wallet ID
+	RequestID    string       `json:"requestId"`    // Client request identifier, Client provided Unique Identifier. (Max 70 characters)
+	Timestamp    int64        `json:"timestamp"`    // Current timestamp in millisecond
 }
 
 // response struct
 
+type SubWalletInfo struct {
+	WalletId          int64  `json:"walletId"`
+	WalletIdStr       string `json:"walletIdStr"`
+	WalletName        string `json:"walletName"`
+	WalletType        uint32 `json:"walletType"`
+	ParentWalletId    int64  `json:"parentWalletId"`
+	ParentWalletIdStr string `json:"parentWalletIdStr"`
+}
+
 type CreatSubWalletResponse struct {
-	Data struct {
-		WalletId          int64  `json:"walletId"`
-		WalletIdStr       string `json:"walletIdStr"`
-		WalletName        string `json:"walletName"`
-		WalletType        uint32 `json:"walletType"`
-		ParentWalletId    int64  `json:"parentWalletId"`
-		ParentWalletIdStr string `json:"parentWalletIdStr"`
-	} `json:"data"`
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Data    SubWalletInfo `json:"data"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
 }
 
 type GetDepositAddressResponse struct {
@@ -59,15 +61,17 @@ type GetDepositAddressResponse struct {
 	Message string `json:"message"`
 }
 
+type TransactionPage struct {
+	Data      []*Transaction `json:"data"`
+	TotalPage int            `json:"totalPage"` // Total number of pages
+	PageNo    int            `json:"pageNo"`    // Current page no
+	PageLimit int            `json:"pageLimit"` // Page limit
+}
+
 type GetDepositHistoryResponse struct {
-	Data struct {
-		Data      []*Transaction `json:"data"`
-		TotalPage int            `json:"totalPage"`
-		PageNo    int            `json:"pageNo"`
-		PageLimit int            `json:"pageLimit"`
-	} `json:"data"`
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Data    TransactionPage `json:"data"`
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
 }
 
 type Transfer struct {
@@ -1,16 +1,16 @@
 package types
 
 type WithdrawalRequest struct {
-	Amount             string `json:"amount"`                       // withdrawal amount
-	CoinSymbol         string `json:"coinSymbol"`                   // coin symbol
-	Memo               string `json:"memo,omitempty"`               // memo/address tag
-	Network            string `json:"network"`                      // network symbol
-	WalletID           int64  `json:"walletId"`                     // wallet id
-	WithdrawalAddress  string `json:"withdrawalAddress"`            // withdrawal address or to wallet id str  must have one
-	ToWalletIDStr      string `json:"toWalletIdStr"`                // to wallet id str  or withdrawal address must have one
-	CustomizeFeeAmount string `json:"customizeFeeAmount,omitempty"` // User-specified fee  , now support eth
-	RequestID          int64  `json:"requestId"`                    // Unique Identifier
-	Timestamp          int64  `json:"timestamp"`                    // Current Timestamp in millisecond
+	Amount             Amount  `json:"amount"`                       // withdrawal amount
+	CoinSymbol         string  `json:"coinSymbol"`                   // coin symbol
+	Memo               string  `json:"memo,omitempty"`               // memo/address tag
+	Network            string  `json:"network"`                      // network symbol
+	WalletID           int64   `json:"walletId"`                     // wallet id
+	WithdrawalAddress  string  `json:"withdrawalAddress"`            // withdrawal address or to wallet id str  must have one
+	ToWalletIDStr      string  `json:"toWalletIdStr"`                // to wallet id str  or withdrawal address must have one
+	CustomizeFeeAmount *Amount `json:"customizeFeeAmount,omitempty"` // User-specified fee  , now support eth
+	RequestID          string  `json:"requestId"`                    // Unique Identifier
+	Timestamp          int64   `json:"timestamp"`                    // Current Timestamp in millisecond
 }
 
 type WithdrawalDetailRequest struct {
@@ -20,14 +20,14 @@ type WithdrawalDetailRequest struct {
 }
 
 type TransferWithExchangeRequest struct {
-	Amount         string `json:"amount"`               // Transfer Amount
+	Amount         Amount `json:"amount"`               // Transfer Amount
 	CoinSymbol     string `json:"coinSymbol,omitempty"` // Coin symbol
 	Direction      int64  `json:"direction,omitempty"`  // Transfer direction,; 10: custody->exchange; 20: exchange->custody
 	ExchangeCode   int64  `json:"exchangeCode"`         // Exchange code, 10: binance
 	ExchangeUserID string `json:"exchangeUserId"`       // Binance UID
 	ParentWalletID int64  `json:"parentWalletId"`       // Parent Wallet Id; (Only applicable to Parent Shared Wallet)
 	Status         int64  `json:"status,omitempty"`     // Status
-	RequestID      int64  `json:"requestId"`            // Unique Identifier
+	RequestID      string `json:"requestId"`            // Unique Identifier
 	Timestamp      int64  `json:"timestamp"`            // Current Timestamp in millisecond
 }
 
@@ -78,9 +78,9 @@ type Transaction struct {
 	ToAddress    string  `json:"toAddress"`
 	Network      string  `json:"network"`
 	CoinSymbol   string  `json:"coinSymbol"`
-	Amount       string  `json:"amount"`
+	Amount       Amount  `json:"amount"`
 	FeeSymbol    string  `json:"feeSymbol"`
-	FeeAmount    string  `json:"feeAmount"`
+	FeeAmount    Amount  `json:"feeAmount"`
 	Status       int64   `json:"status"`
 	Memo         *string `json:"memo"`
 	TxTime       string  `json:"txTime"`
@@ -95,7 +95,7 @@ type TransferWithExchangeResponse struct {
 }
 
 type TransferDetail struct {
-	Amount         string `json:"amount"`
+	Amount         Amount `json:"amount"`
 	CoinSymbol     string `json:"coinSymbol"`
 	Direction      int32  `json:"direction"`
 	ExchangeCode   int32  `json:"exchangeCode"`
@@ -0,0 +1,366 @@
+// Package watcher tracks the status of withdrawals and transfers with Exchange by
+// polling the Ceffu detail endpoints, and reports every status change until the
+// orders reach a final status.
+//
+//	w := watcher.New(c, watcher.Options{})
+//	go w.Run(ctx)
+//
+//	w.WatchWithdrawal(withdrawal.OrderViewId)
+//	for update := range w.Updates() {
+//		...
+//	}
+package watcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+const (
+	DefaultConcurrency = 4
+	DefaultMinInterval = 2 * time.Second
+	DefaultMaxInterval = time.Minute
+)
+
+var (
+	// ErrOrderNotFound is passed to Options.OnError when the detail of an order is empty.
+	ErrOrderNotFound = errors.New("watcher: order not found")
+	// ErrAlreadyRun is returned by Run when it is called more than once.
+	ErrAlreadyRun = errors.New("watcher: Run called more than once")
+)
+
+// Kind is the kind of order tracked by the watcher.
+type Kind int
+
+const (
+	KindWithdrawal       Kind = iota + 1 // polled with WithdrawalDetail
+	KindExchangeTransfer                 // polled with TransferDetailWithExchange
+)
+
+// Update is a status change of a tracked order.
+type Update struct {
+	Kind        Kind
+	OrderViewID string
+	// PreviousStatus is 0 on the first update of an order.
+	PreviousStatus int64
+	Status         int64
+	// Final reports whether the order reached a final status and is no longer tracked.
+	Final bool
+
+	// The detail of the order, depending on Kind.
+	Withdrawal       *types.Transaction
+	ExchangeTransfer *types.TransferDetail
+}
+
+type Options struct {
+	// Concurrency is the maximum number of requests in flight, DefaultConcurrency if 0.
+	Concurrency int
+	// MinInterval is the polling interval of an order after it changed status, DefaultMinInterval if 0.
+	MinInterval time.Duration
+	// MaxInterval is the longest polling interval, DefaultMaxInterval if 0. The interval of
+	// an order doubles every time it is polled without a status change.
+	MaxInterval time.Duration
+	// FinalStatuses are the statuses after which an order is no longer tracked.
+	// Defaults to TransactionStatusSuccess, TransactionStatusConfirmed and TransactionStatusFailed.
+	FinalStatuses []int64
+	// OnUpdate receives the updates instead of the Updates channel if set.
+	OnUpdate func(Update)
+	// OnError is called when polling an order fails. The order is polled again later.
+	OnError func(orderViewID string, err error)
+	// BufferSize is the buffer size of the Updates channel.
+	BufferSize int
+}
+
+// orderKey identifies a tracked order: orderViewIds of different kinds may collide.
+type orderKey struct {
+	kind        Kind
+	orderViewID string
+}
+
+type tracked struct {
+	kind        Kind
+	orderViewID string
+	walletID    int64
+	status      int64
+	interval    time.Duration
+	next        time.Time
+	polling     bool
+}
+
+// Watcher polls the status of many orders with a bounded concurrency. It is safe for concurrent use.
+type Watcher struct {
+	client  client.Client
+	opts    Options
+	updates chan Update
+	wake    chan struct{}
+
+	mu     sync.Mutex
+	orders map[orderKey]*tracked
+	ran    bool
+}
+
+func New(c client.Client, opts Options) *Watcher {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.MinInterval <= 0 {
+		opts.MinInterval = DefaultMinInterval
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = DefaultMaxInterval
+	}
+	if opts.MaxInterval < opts.MinInterval {
+		opts.MaxInterval = opts.MinInterval
+	}
+	if opts.FinalStatuses == nil {
+		opts.FinalStatuses = []int64{
+			types.TransactionStatusSuccess,
+			types.TransactionStatusConfirmed,
+			types.TransactionStatusFailed,
+		}
+	}
+	return &Watcher{
+		client:  c,
+		opts:    opts,
+		updates: make(chan Update, opts.BufferSize),
+		wake:    make(chan struct{}, 1),
+		orders:  make(map[orderKey]*tracked),
+	}
+}
+
+// Updates returns the channel receiving the updates, unless Options.OnUpdate is set.
+// The channel is closed when Run returns.
+func (w *Watcher) Updates() <-chan Update {
+	return w.updates
+}
+
+// WatchWithdrawal starts tracking a withdrawal.
+func (w *Watcher) WatchWithdrawal(orderViewID string) {
+	w.watch(&tracked{kind: KindWithdrawal, orderViewID: orderViewID})
+}
+
+// WatchExchangeTransfer starts tracking a transfer with Exchange of the wallet.
+func (w *Watcher) WatchExchangeTransfer(orderViewID string, walletID int64) {
+	w.watch(&tracked{kind: KindExchangeTransfer, orderViewID: orderViewID, walletID: walletID})
+}
+
+func (w *Watcher) watch(o *tracked) {
+	o.interval = w.opts.MinInterval
+	o.next = time.Now()
+
+	w.mu.Lock()
+	if _, ok := w.orders[o.key()]; !ok {
+		w.orders[o.key()] = o
+	}
+	w.mu.Unlock()
+
+	w.signal()
+}
+
+// signal wakes up Run to reschedule the orders.
+func (w *Watcher) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *tracked) key() orderKey {
+	return orderKey{kind: o.kind, orderViewID: o.orderViewID}
+}
+
+// Unwatch stops tracking an order of the kind.
+func (w *Watcher) Unwatch(kind Kind, orderViewID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.orders, orderKey{kind: kind, orderViewID: orderViewID})
+}
+
+// Len returns the number of tracked orders.
+func (w *Watcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.orders)
+}
+
+// Run polls the tracked orders until ctx is done, and returns ctx.Err().
+// It can be called only once, and fails with ErrAlreadyRun afterwards.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	ran := w.ran
+	w.ran = true
+	w.mu.Unlock()
+	if ran {
+		return ErrAlreadyRun
+	}
+	defer close(w.updates)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, w.opts.Concurrency)
+	for {
+		due, wait := w.due(time.Now())
+		for i, o := range due {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				w.release(due[i:])
+				return ctx.Err()
+			}
+			wg.Add(1)
+			go func(o *tracked) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				w.poll(ctx, o)
+			}(o)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-w.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// due returns the orders to poll now, and the time to wait before the next one is due.
+func (w *Watcher) due(now time.Time) ([]*tracked, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var due []*tracked
+	wait := w.opts.MaxInterval
+	for _, o := range w.orders {
+		if o.polling {
+			continue
+		}
+		if !o.next.After(now) {
+			o.polling = true
+			due = append(due, o)
+			continue
+		}
+		if d := o.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return due, wait
+}
+
+// release reschedules due orders that will not be polled.
+func (w *Watcher) release(orders []*tracked) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, o := range orders {
+		o.polling = false
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context, o *tracked) {
+	update, err := w.fetch(ctx, o)
+
+	w.mu.Lock()
+	if w.orders[o.key()] != o {
+		// unwatched while polling
+		o.polling = false
+		w.mu.Unlock()
+		return
+	}
+	if err != nil || update.Status == o.status {
+		o.polling = false
+		// back off while nothing changes
+		o.interval *= 2
+		if o.interval > w.opts.MaxInterval {
+			o.interval = w.opts.MaxInterval
+		}
+		o.next = time.Now().Add(o.interval)
+		w.mu.Unlock()
+		w.signal()
+
+		if err != nil && ctx.Err() == nil && w.opts.OnError != nil {
+			w.opts.OnError(o.orderViewID, err)
+		}
+		return
+	}
+
+	update.PreviousStatus = o.status
+	update.Final = w.isFinal(update.Status)
+	w.mu.Unlock()
+
+	// the order is marked as polling until the update is delivered, and stays tracked
+	// with its previous status if ctx is done first, even if the update is final
+	delivered := w.emit(ctx, update)
+
+	w.mu.Lock()
+	o.polling = false
+	if delivered {
+		o.status = update.Status
+		o.interval = w.opts.MinInterval
+		if update.Final && w.orders[o.key()] == o {
+			delete(w.orders, o.key())
+		}
+	}
+	o.next = time.Now().Add(o.interval)
+	w.mu.Unlock()
+	w.signal()
+}
+
+func (w *Watcher) fetch(ctx context.Context, o *tracked) (Update, error) {
+	update := Update{Kind: o.kind, OrderViewID: o.orderViewID}
+	switch o.kind {
+	case KindWithdrawal:
+		tx, err := w.client.WithdrawalDetail(ctx, o.orderViewID)
+		if err != nil {
+			return update, err
+		}
+		if tx == nil {
+			return update, ErrOrderNotFound
+		}
+		update.Withdrawal, update.Status = tx, tx.Status
+	case KindExchangeTransfer:
+		transfer, err := w.client.TransferDetailWithExchange(ctx, o.orderViewID, o.walletID)
+		if err != nil {
+			return update, err
+		}
+		if transfer == nil {
+			return update, ErrOrderNotFound
+		}
+		update.ExchangeTransfer, update.Status = transfer, int64(transfer.Status)
+	}
+	return update, nil
+}
+
+func (w *Watcher) isFinal(status int64) bool {
+	for _, final := range w.opts.FinalStatuses {
+		if status == final {
+			return true
+		}
+	}
+	return false
+}
+
+// emit delivers the update and reports whether it was delivered before ctx was done.
+func (w *Watcher) emit(ctx context.Context, update Update) bool {
+	if w.opts.OnUpdate != nil {
+		w.opts.OnUpdate(update)
+		return true
+	}
+	select {
+	case w.updates <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,196 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/ceffu-go/ceffutest"
+	"github.com/mapprotocol/ceffu-go/client"
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+// withdraw starts a pending withdrawal on the fake server and returns its orderViewId.
+func withdraw(t *testing.T, srv *ceffutest.Server, c client.Client) string {
+	t.Helper()
+	walletID := srv.CreatePrimeWallet("prime")
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+	if err := srv.SetBalance(walletID, "ETH", "ETH", types.MustParseAmount("10")); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.WhitelistAddress(walletID, "ETH", address); err != nil {
+		t.Fatal(err)
+	}
+	withdrawal, err := c.Withdrawal(context.Background(), &types.WithdrawalRequest{
+		WalletID:          walletID,
+		CoinSymbol:        "ETH",
+		Network:           "ETH",
+		Amount:            types.MustParseAmount("1"),
+		WithdrawalAddress: address,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return withdrawal.OrderViewId
+}
+
+func polls(srv *ceffutest.Server) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Path == client.PathWithdrawalDetail {
+			n++
+		}
+	}
+	return n
+}
+
+func TestAdaptivePolling(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c, err := srv.Client(client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderViewID := withdraw(t, srv, c)
+
+	w := New(c, Options{MinInterval: 20 * time.Millisecond, MaxInterval: 2 * time.Second, BufferSize: 10})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+	w.WatchWithdrawal(orderViewID)
+
+	update := <-w.Updates()
+	if update.Status != types.TransactionStatusPending || update.PreviousStatus != 0 || update.Final {
+		t.Fatalf("first update = %+v", update)
+	}
+
+	// unchanged polls back off from MinInterval: 20ms, 40ms, 80ms, 160ms, 320ms, ...
+	time.Sleep(700 * time.Millisecond)
+	if n := polls(srv); n < 5 || n > 7 {
+		t.Errorf("%d polls in 700ms, want 5 to 7", n)
+	}
+
+	// a status change is reported at the next poll, then the interval is reset
+	if _, err := srv.Advance(orderViewID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case update = <-w.Updates():
+	case <-time.After(time.Second):
+		t.Fatal("no update within 1s of the status change")
+	}
+	if update.Status != types.TransactionStatusProcessing || update.PreviousStatus != types.TransactionStatusPending {
+		t.Errorf("update = %+v", update)
+	}
+	if _, err := srv.Advance(orderViewID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case update = <-w.Updates():
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("no update within 200ms of the status change after a reset interval")
+	}
+	if update.Status != types.TransactionStatusSuccess || !update.Final {
+		t.Errorf("update = %+v, want final success", update)
+	}
+	// the order is removed once the final update is delivered
+	for deadline := time.Now().Add(time.Second); w.Len() != 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d orders tracked after the final status", w.Len())
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run = %v", err)
+	}
+	if _, ok := <-w.Updates(); ok {
+		t.Error("Updates is not closed after Run returned")
+	}
+	if err := w.Run(context.Background()); !errors.Is(err, ErrAlreadyRun) {
+		t.Errorf("second Run = %v, want ErrAlreadyRun", err)
+	}
+}
+
+func TestCancelWhileWaitingForConcurrency(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c, err := srv.Client(client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.InjectFault(ceffutest.Fault{Path: client.PathWithdrawalDetail, Latency: 100 * time.Millisecond})
+
+	w := New(c, Options{Concurrency: 1, MinInterval: time.Hour})
+	for _, orderViewID := range []string{"1", "2", "3", "4"} {
+		w.WatchWithdrawal(orderViewID)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run = %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, o := range w.orders {
+		if o.polling {
+			t.Errorf("order %s is still marked as polling after Run returned", key.orderViewID)
+		}
+	}
+}
+
+func TestUndeliveredFinalUpdate(t *testing.T) {
+	srv := ceffutest.NewServer()
+	defer srv.Close()
+	c, err := srv.Client(client.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderViewID := withdraw(t, srv, c)
+	for i := 0; i < 2; i++ {
+		if _, err := srv.Advance(orderViewID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// nobody receives from Updates: the final update is not delivered before ctx is done
+	w := New(c, Options{MinInterval: time.Hour})
+	w.WatchWithdrawal(orderViewID)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run = %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	o, ok := w.orders[orderKey{KindWithdrawal, orderViewID}]
+	if !ok {
+		t.Fatal("the order is not tracked after its final update was lost")
+	}
+	if o.status != 0 || o.polling {
+		t.Errorf("order status %d, polling %v, want 0 and false", o.status, o.polling)
+	}
+}
+
+func TestOrderKinds(t *testing.T) {
+	w := New(nil, Options{})
+	w.WatchWithdrawal("1")
+	w.WatchExchangeTransfer("1", 10)
+	w.WatchWithdrawal("1")
+	if w.Len() != 2 {
+		t.Errorf("%d orders tracked, want a withdrawal and a transfer with the same orderViewId", w.Len())
+	}
+	w.Unwatch(KindWithdrawal, "1")
+	w.mu.Lock()
+	_, ok := w.orders[orderKey{KindExchangeTransfer, "1"}]
+	w.mu.Unlock()
+	if w.Len() != 1 || !ok {
+		t.Errorf("Unwatch of the withdrawal removed the transfer")
+	}
+}
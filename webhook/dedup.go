@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// DefaultDeduplicatorSize is the number of events remembered by the default deduplicator.
+const DefaultDeduplicatorSize = 10000
+
+var (
+	// ErrDuplicateEvent is returned by Deduplicator.Reserve for an event already handled.
+	// The notification is acknowledged without calling the callback.
+	ErrDuplicateEvent = errors.New("webhook: duplicate event")
+	// ErrEventInProgress is returned by Deduplicator.Reserve for an event being handled by
+	// a concurrent delivery. The notification is rejected, so that Ceffu delivers it again
+	// if the concurrent delivery fails.
+	ErrEventInProgress = errors.New("webhook: event in progress")
+)
+
+// Deduplicator remembers the events that have been handled. An event is reserved
+// before its callback is called, then marked if the callback succeeded, or released
+// so that it is handled again when Ceffu delivers it again.
+type Deduplicator interface {
+	// Reserve atomically reserves the key of an event. It fails with ErrDuplicateEvent
+	// if the event was marked, and with ErrEventInProgress if it is already reserved.
+	Reserve(key string) error
+	// Mark records a reserved event as handled.
+	Mark(key string)
+	// Release releases a reserved event whose callback failed.
+	Release(key string)
+}
+
+// MemoryDeduplicator is an in-memory Deduplicator remembering the most recent events.
+type MemoryDeduplicator struct {
+	mu       sync.Mutex
+	size     int
+	reserved map[string]bool
+	keys     map[string]*list.Element
+	order    *list.List
+}
+
+// NewMemoryDeduplicator returns a deduplicator remembering up to size events.
+func NewMemoryDeduplicator(size int) *MemoryDeduplicator {
+	if size <= 0 {
+		size = DefaultDeduplicatorSize
+	}
+	return &MemoryDeduplicator{
+		size:     size,
+		reserved: make(map[string]bool),
+		keys:     make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (d *MemoryDeduplicator) Reserve(key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.keys[key]; ok {
+		return ErrDuplicateEvent
+	}
+	if d.reserved[key] {
+		return ErrEventInProgress
+	}
+	d.reserved[key] = true
+	return nil
+}
+
+func (d *MemoryDeduplicator) Mark(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.reserved, key)
+	if _, ok := d.keys[key]; ok {
+		return
+	}
+	d.keys[key] = d.order.PushBack(key)
+	if d.order.Len() > d.size {
+		oldest := d.order.Front()
+		d.order.Remove(oldest)
+		delete(d.keys, oldest.Value.(string))
+	}
+}
+
+func (d *MemoryDeduplicator) Release(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.reserved, key)
+}
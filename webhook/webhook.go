@@ -0,0 +1,232 @@
+// Package webhook implements an http.Handler receiving Ceffu push notifications
+// for deposits, withdrawals and transfers.
+//
+// Every notification is verified with client.Verify against the Ceffu public key, its
+// payload is decrypted with client.Decode when it is in the "encoded" field, and passed
+// as JSON to the callback. Ceffu's notification formats are not published, so the handler
+// does not decode the payload: the callback identifies the event, e.g. from the fields
+// of the notifications configured for the account:
+//
+//	handler := webhook.New(ceffuPublicKey, webhook.Options{
+//		OnNotification: func(ctx context.Context, payload json.RawMessage) error {
+//			var tx types.Transaction
+//			if err := json.Unmarshal(payload, &tx); err != nil {
+//				return err
+//			}
+//			return credit(ctx, &tx)
+//		},
+//	})
+//	http.Handle("/ceffu/notify", handler)
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mapprotocol/ceffu-go/client"
+)
+
+// MaxBodySize is the maximum size of a notification body, larger ones are rejected
+// with status 413.
+const MaxBodySize = 1 << 20
+
+var (
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrInvalidPayload   = errors.New("webhook: invalid payload")
+)
+
+type Options struct {
+	// PrivateKey decrypts the "encoded" field of notifications, see client.Decode.
+	// It is required only if the notifications are encrypted.
+	PrivateKey *rsa.PrivateKey
+	// Deduplicator drops notifications that have already been handled. A NewMemoryDeduplicator
+	// of DefaultDeduplicatorSize is used if nil.
+	Deduplicator Deduplicator
+	// Acknowledgement is the response body of handled notifications, sent with status 200
+	// and Content-Type application/json. The body is empty if nil. Ceffu does not publish
+	// the response it expects, set it to the one configured for the account.
+	Acknowledgement json.RawMessage
+	// EventKey identifies the event of a payload for deduplication, e.g. its orderViewId
+	// and status so that every status change of an order is handled once. The SHA-256 of
+	// the payload is used if nil, so that only identical deliveries are dropped.
+	EventKey func(payload json.RawMessage) (string, error)
+
+	// OnNotification is called with the JSON payload of every verified notification.
+	// The notification is rejected with status 500 if it fails, so that Ceffu delivers
+	// it again.
+	OnNotification func(ctx context.Context, payload json.RawMessage) error
+	// OnError is called when a notification is rejected, e.g. because its signature is invalid.
+	OnError func(ctx context.Context, err error)
+}
+
+// Handler is an http.Handler receiving Ceffu push notifications.
+type Handler struct {
+	publicKey *rsa.PublicKey
+	opts      Options
+}
+
+// New returns a handler verifying notifications with the Ceffu public key.
+func New(publicKey *rsa.PublicKey, opts Options) *Handler {
+	if opts.Deduplicator == nil {
+		opts.Deduplicator = NewMemoryDeduplicator(DefaultDeduplicatorSize)
+	}
+	if opts.EventKey == nil {
+		opts.EventKey = payloadHash
+	}
+	return &Handler{
+		publicKey: publicKey,
+		opts:      opts,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		// MaxBytesReader fails once MaxBodySize bytes are read (http.MaxBytesError needs Go 1.19)
+		status := http.StatusBadRequest
+		if len(body) == MaxBodySize {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.reject(r.Context(), w, status, err)
+		return
+	}
+
+	if err := h.Handle(r.Context(), body); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidSignature):
+			status = http.StatusUnauthorized
+		case errors.Is(err, ErrInvalidPayload):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrEventInProgress):
+			status = http.StatusConflict
+		}
+		h.reject(r.Context(), w, status, err)
+		return
+	}
+	if h.opts.Acknowledgement != nil {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.opts.Acknowledgement)
+}
+
+// Handle verifies a notification body and passes its payload to the callback. It is used
+// by ServeHTTP and can be called directly when notifications are received by other means.
+// Events already handled are dropped, and concurrent deliveries of an event fail with
+// ErrEventInProgress while its callback runs.
+func (h *Handler) Handle(ctx context.Context, body []byte) error {
+	notification, err := decodeObject(body)
+	if err != nil {
+		return err
+	}
+
+	sign, _ := notification["sign"].(string)
+	if sign == "" {
+		return ErrInvalidSignature
+	}
+	// Verify removes the "sign" and "encoded" fields, keep them for decoding the payload
+	signed := make(map[string]interface{}, len(notification))
+	for key, value := range notification {
+		signed[key] = value
+	}
+	if ok, err := client.Verify(h.publicKey, signed, sign); !ok || err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	payload, err := h.payload(notification)
+	if err != nil {
+		return err
+	}
+	// the payload is not decoded, but must be a JSON object
+	if _, err := decodeObject(payload); err != nil {
+		return err
+	}
+
+	key, err := h.opts.EventKey(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if err := h.opts.Deduplicator.Reserve(key); err != nil {
+		if errors.Is(err, ErrDuplicateEvent) {
+			return nil
+		}
+		return err
+	}
+	if err := h.dispatch(ctx, payload); err != nil {
+		h.opts.Deduplicator.Release(key)
+		return err
+	}
+	h.opts.Deduplicator.Mark(key)
+	return nil
+}
+
+// payload returns the event payload of a notification: the decrypted "encoded" field,
+// the "data" field, an object or an object encoded in a string, or else the notification itself.
+func (h *Handler) payload(notification map[string]interface{}) ([]byte, error) {
+	if encoded, _ := notification["encoded"].(string); encoded != "" {
+		if h.opts.PrivateKey == nil {
+			return nil, fmt.Errorf("%w: encrypted notification without private key", ErrInvalidPayload)
+		}
+		decrypted, err := client.Decode(h.opts.PrivateKey, encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		return decrypted, nil
+	}
+
+	payload := notification
+	switch data := notification["data"].(type) {
+	case map[string]interface{}:
+		payload = data
+	case string:
+		return []byte(data), nil
+	}
+	delete(payload, "sign")
+	return json.Marshal(payload)
+}
+
+func (h *Handler) dispatch(ctx context.Context, payload json.RawMessage) error {
+	if h.opts.OnNotification == nil {
+		return nil
+	}
+	return h.opts.OnNotification(ctx, payload)
+}
+
+func (h *Handler) reject(ctx context.Context, w http.ResponseWriter, status int, err error) {
+	if h.opts.OnError != nil {
+		h.opts.OnError(ctx, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// payloadHash is the default Options.EventKey.
+func payloadHash(payload json.RawMessage) (string, error) {
+	hash := sha256.Sum256(payload)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// decodeObject decodes a JSON object, keeping numbers as json.Number so that
+// the payload is re-encoded exactly as signed.
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	object := map[string]interface{}{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return object, nil
+}
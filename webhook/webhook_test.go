@@ -0,0 +1,360 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mapprotocol/ceffu-go/types"
+)
+
+var (
+	ceffuKey, _    = rsa.GenerateKey(rand.Reader, 2048)
+	receiverKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+)
+
+// notify returns a notification signed with the Ceffu key, as checked by client.Verify.
+func notify(t *testing.T, notification map[string]interface{}) []byte {
+	t.Helper()
+	signed := make(map[string]interface{})
+	for key, value := range notification {
+		if key != "encoded" {
+			signed[key] = value
+		}
+	}
+	data, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := sha256.Sum256(data)
+	sign, err := rsa.SignPKCS1v15(rand.Reader, ceffuKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	notification["sign"] = base64.StdEncoding.EncodeToString(sign)
+	body, err := json.Marshal(notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func deposit(orderViewID string, status int) map[string]interface{} {
+	return map[string]interface{}{
+		"orderViewId": orderViewID,
+		"direction":   types.TransactionDirectionDeposit,
+		"coinSymbol":  "ETH",
+		"network":     "ETH",
+		"amount":      "1.000000000000000001",
+		"status":      status,
+	}
+}
+
+// orderKey is an Options.EventKey keying events by orderViewId and status.
+func orderKey(payload json.RawMessage) (string, error) {
+	var order struct {
+		OrderViewID string `json:"orderViewId"`
+		Status      int64  `json:"status"`
+	}
+	if err := json.Unmarshal(payload, &order); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%d", order.OrderViewID, order.Status), nil
+}
+
+func post(h http.Handler, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", bytes.NewReader(body)))
+	return w
+}
+
+func TestSignature(t *testing.T) {
+	calls := 0
+	h := New(&ceffuKey.PublicKey, Options{
+		OnNotification: func(ctx context.Context, payload json.RawMessage) error {
+			calls++
+			return nil
+		},
+	})
+
+	body := notify(t, deposit("1", 10))
+	if w := post(h, body); w.Code != http.StatusOK {
+		t.Fatalf("valid notification: status %d", w.Code)
+	}
+
+	tampered := bytes.Replace(body, []byte("1.000000000000000001"), []byte("9.000000000000000001"), 1)
+	unsigned := map[string]interface{}{"orderViewId": "2", "direction": types.TransactionDirectionDeposit}
+	unsignedBody, _ := json.Marshal(unsigned)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other := New(&otherKey.PublicKey, Options{})
+
+	tests := []struct {
+		name string
+		h    http.Handler
+		body []byte
+		want int
+	}{
+		{"tampered", h, tampered, http.StatusUnauthorized},
+		{"unsigned", h, unsignedBody, http.StatusUnauthorized},
+		{"other key", other, notify(t, deposit("3", 10)), http.StatusUnauthorized},
+		{"malformed", h, []byte("{"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := post(tt.h, tt.body); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("OnNotification called %d times, want 1", calls)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notify", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d", w.Code)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	var got []types.Transaction
+	h := New(&ceffuKey.PublicKey, Options{
+		PrivateKey: receiverKey,
+		OnNotification: func(ctx context.Context, payload json.RawMessage) error {
+			var tx types.Transaction
+			if err := json.Unmarshal(payload, &tx); err != nil {
+				return err
+			}
+			got = append(got, tx)
+			return nil
+		},
+	})
+
+	data, _ := json.Marshal(deposit("string", 10))
+	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, &receiverKey.PublicKey, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		notification map[string]interface{}
+		want         string
+	}{
+		{"flat", deposit("flat", 10), "flat"},
+		{"data object", map[string]interface{}{"data": deposit("object", 10)}, "object"},
+		{"data string", map[string]interface{}{"data": string(data)}, "string"},
+		{"encoded", map[string]interface{}{"encoded": base64.StdEncoding.EncodeToString(encrypted), "timestamp": 1}, "string"},
+	}
+	for _, tt := range tests {
+		got = nil
+		// the decrypted and string payloads are the same event: let every case be handled
+		h.opts.Deduplicator = NewMemoryDeduplicator(0)
+		if err := h.Handle(context.Background(), notify(t, tt.notification)); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(got) != 1 || got[0].OrderViewID != tt.want || got[0].Amount.String() != "1.000000000000000001" {
+			t.Errorf("%s: payload %+v, want deposit %s of 1.000000000000000001", tt.name, got, tt.want)
+		}
+	}
+
+	if err := h.Handle(context.Background(), notify(t, map[string]interface{}{"data": "not JSON"})); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("invalid data: %v, want ErrInvalidPayload", err)
+	}
+	noKey := New(&ceffuKey.PublicKey, Options{})
+	encoded := map[string]interface{}{"encoded": base64.StdEncoding.EncodeToString(encrypted)}
+	if err := noKey.Handle(context.Background(), notify(t, encoded)); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("encoded without private key: %v, want ErrInvalidPayload", err)
+	}
+}
+
+func TestAcknowledgement(t *testing.T) {
+	w := post(New(&ceffuKey.PublicKey, Options{}), notify(t, deposit("1", 10)))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("default acknowledgement: status %d, body %q", w.Code, w.Body)
+	}
+
+	custom := json.RawMessage(`{"code":"SUCCESS"}`)
+	w = post(New(&ceffuKey.PublicKey, Options{Acknowledgement: custom}), notify(t, deposit("1", 10)))
+	if w.Code != http.StatusOK || w.Body.String() != string(custom) || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("custom acknowledgement: status %d, body %q", w.Code, w.Body)
+	}
+}
+
+func TestBodySize(t *testing.T) {
+	h := New(&ceffuKey.PublicKey, Options{})
+	large := notify(t, map[string]interface{}{"padding": strings.Repeat("a", MaxBodySize)})
+	if w := post(h, large); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: status %d, want 413", w.Code)
+	}
+	if w := post(h, notify(t, deposit("1", 10))); w.Code != http.StatusOK {
+		t.Errorf("small body: status %d", w.Code)
+	}
+}
+
+func TestDeduplication(t *testing.T) {
+	calls := 0
+	fail := true
+	h := New(&ceffuKey.PublicKey, Options{
+		EventKey: orderKey,
+		OnNotification: func(ctx context.Context, payload json.RawMessage) error {
+			calls++
+			if fail {
+				return errors.New("database unavailable")
+			}
+			return nil
+		},
+	})
+
+	body := notify(t, deposit("1", 10))
+	if w := post(h, body); w.Code != http.StatusInternalServerError {
+		t.Fatalf("failed callback: status %d", w.Code)
+	}
+	fail = false
+	for i := 0; i < 3; i++ {
+		if w := post(h, body); w.Code != http.StatusOK {
+			t.Fatalf("delivery %d: status %d", i, w.Code)
+		}
+	}
+	// every status change is an event of its own
+	if w := post(h, notify(t, deposit("1", 30))); w.Code != http.StatusOK {
+		t.Fatalf("status change: status %d", w.Code)
+	}
+	if calls != 3 {
+		t.Errorf("OnNotification called %d times, want 3: failed, first success and status change", calls)
+	}
+
+	// without EventKey, only identical deliveries are dropped
+	calls = 0
+	h = New(&ceffuKey.PublicKey, Options{
+		OnNotification: func(ctx context.Context, payload json.RawMessage) error {
+			calls++
+			return nil
+		},
+	})
+	body = notify(t, deposit("1", 10))
+	for _, body := range [][]byte{body, body, notify(t, deposit("1", 30))} {
+		if w := post(h, body); w.Code != http.StatusOK {
+			t.Fatalf("status %d", w.Code)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("OnNotification called %d times without EventKey, want 2", calls)
+	}
+
+	failing := New(&ceffuKey.PublicKey, Options{
+		EventKey: func(payload json.RawMessage) (string, error) {
+			return "", errors.New("no orderViewId")
+		},
+	})
+	if w := post(failing, notify(t, deposit("1", 10))); w.Code != http.StatusBadRequest {
+		t.Errorf("failing EventKey: status %d, want 400", w.Code)
+	}
+}
+
+func TestConcurrentDeliveries(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	calls := 0
+	h := New(&ceffuKey.PublicKey, Options{
+		OnNotification: func(ctx context.Context, payload json.RawMessage) error {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			close(started)
+			<-release
+			return errors.New("credit failed")
+		},
+	})
+	body := notify(t, deposit("1", 10))
+
+	done := make(chan int)
+	go func() {
+		done <- post(h, body).Code
+	}()
+	<-started
+	// a concurrent delivery is rejected while the first one is handled, not acknowledged
+	for i := 0; i < 5; i++ {
+		if w := post(h, body); w.Code != http.StatusConflict {
+			t.Errorf("concurrent delivery: status %d, want 409", w.Code)
+		}
+	}
+	close(release)
+	if status := <-done; status != http.StatusInternalServerError {
+		t.Errorf("first delivery: status %d, want 500", status)
+	}
+
+	// the failed event is handled again on the next delivery
+	h.opts.OnNotification = func(ctx context.Context, payload json.RawMessage) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return nil
+	}
+	if w := post(h, body); w.Code != http.StatusOK {
+		t.Errorf("redelivery: status %d", w.Code)
+	}
+	if calls != 2 {
+		t.Errorf("OnNotification called %d times, want 2", calls)
+	}
+}
+
+func TestMemoryDeduplicator(t *testing.T) {
+	d := NewMemoryDeduplicator(2)
+	if err := d.Reserve("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reserve("a"); !errors.Is(err, ErrEventInProgress) {
+		t.Errorf("Reserve of a reserved key: %v", err)
+	}
+	d.Release("a")
+	if err := d.Reserve("a"); err != nil {
+		t.Errorf("Reserve of a released key: %v", err)
+	}
+	d.Mark("a")
+	if err := d.Reserve("a"); !errors.Is(err, ErrDuplicateEvent) {
+		t.Errorf("Reserve of a marked key: %v", err)
+	}
+
+	// the oldest keys are forgotten
+	for _, key := range []string{"b", "c"} {
+		if err := d.Reserve(key); err != nil {
+			t.Fatal(err)
+		}
+		d.Mark(key)
+	}
+	if err := d.Reserve("a"); err != nil {
+		t.Errorf("Reserve of an evicted key: %v", err)
+	}
+
+	// concurrent reservations of a key: exactly one wins
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	won := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d.Reserve("z") == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Errorf("%d concurrent reservations succeeded, want 1", won)
+	}
+}